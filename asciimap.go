@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Size of the -map grid in characters.
+const (
+	asciiMapWidth  = 80
+	asciiMapHeight = 24
+)
+
+// plotSymbols marks an epicenter on the ASCII map, by whole magnitude:
+// below 5, 5s, 6s, and 7 or more.
+var plotSymbols = []byte{'.', 'o', 'O', '@'}
+
+// plotSymbol picks the map character for a magnitude.
+func plotSymbol(mag float64) byte {
+	switch {
+	case mag >= 7:
+		return plotSymbols[3]
+	case mag >= 6:
+		return plotSymbols[2]
+	case mag >= 5:
+		return plotSymbols[1]
+	default:
+		return plotSymbols[0]
+	}
+}
+
+// plotASCII plots the features on a width x height character grid using an
+// equirectangular projection, framed by a border. Where several epicenters
+// share a cell the strongest symbol wins; features without coordinates are
+// skipped.
+func plotASCII(features []Feature, width, height int) string {
+	grid := make([][]byte, height)
+	for row := range grid {
+		grid[row] = []byte(strings.Repeat(" ", width))
+	}
+
+	for _, feature := range features {
+		coordinates := feature.Geometry.Coordinates
+		if len(coordinates) < 2 {
+			continue
+		}
+		longitude, latitude := coordinates[0], coordinates[1]
+
+		col := int((longitude + 180) / 360 * float64(width-1))
+		row := int((90 - latitude) / 180 * float64(height-1))
+		if col < 0 || col >= width || row < 0 || row >= height {
+			continue
+		}
+
+		mag, _ := feature.Properties.magnitude()
+		symbol := plotSymbol(mag)
+		if bytes.IndexByte(plotSymbols, symbol) > bytes.IndexByte(plotSymbols, grid[row][col]) {
+			grid[row][col] = symbol
+		}
+	}
+
+	border := "+" + strings.Repeat("-", width) + "+\n"
+
+	var b strings.Builder
+	b.WriteString(border)
+	for _, line := range grid {
+		b.WriteString("|")
+		b.Write(line)
+		b.WriteString("|\n")
+	}
+	b.WriteString(border)
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlotASCII(t *testing.T) {
+	point := func(mag, lon, lat float64) Feature {
+		return Feature{Properties: Properties{Mag: &mag}, Geometry: Geometry{Coordinates: []float64{lon, lat, 10}}}
+	}
+	unplotted := 8.0
+	features := []Feature{
+		point(4.5, -180, 90),
+		point(7.2, 180, -90),
+		point(5.1, 0, 0),
+		point(6.3, 0, 0),
+		{Properties: Properties{Mag: &unplotted}},
+	}
+
+	lines := strings.Split(strings.TrimSuffix(plotASCII(features, 9, 5), "\n"), "\n")
+
+	expected := []string{
+		"+---------+",
+		"|.        |",
+		"|         |",
+		"|    O    |",
+		"|         |",
+		"|        @|",
+		"+---------+",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected map:\n%s\nExpected:\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestPlotSymbol(t *testing.T) {
+	tests := []struct {
+		mag      float64
+		expected byte
+	}{
+		{2.5, '.'},
+		{5, 'o'},
+		{6.9, 'O'},
+		{9.1, '@'},
+	}
+
+	for _, tt := range tests {
+		if got := plotSymbol(tt.mag); got != tt.expected {
+			t.Errorf("plotSymbol(%v): expected %q, got %q", tt.mag, tt.expected, got)
+		}
+	}
+}
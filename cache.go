@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheDir returns the directory holding cached feeds. It is a variable so
+// tests can point it at a temporary directory.
+var cacheDir = func() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "eqk"), nil
+}
+
+// cacheMetadata is stored next to each cached body.
+type cacheMetadata struct {
+	URL     string    `json:"url"`
+	Fetched time.Time `json:"fetched"`
+	ETag    string    `json:"etag,omitempty"`
+}
+
+// feedCache locates the cached body and metadata for one feed URL.
+type feedCache struct {
+	bodyPath     string
+	metadataPath string
+}
+
+// cacheFor returns the cache files for a feed URL.
+func cacheFor(feedURL string) (feedCache, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return feedCache{}, err
+	}
+
+	sum := sha256.Sum256([]byte(feedURL))
+	key := hex.EncodeToString(sum[:])
+	return feedCache{
+		bodyPath:     filepath.Join(dir, key+".geojson"),
+		metadataPath: filepath.Join(dir, key+".meta.json"),
+	}, nil
+}
+
+// read returns the cached body and its metadata.
+func (c feedCache) read() ([]byte, cacheMetadata, error) {
+	metadata, err := c.readMetadata()
+	if err != nil {
+		return nil, cacheMetadata{}, err
+	}
+
+	body, err := os.ReadFile(c.bodyPath)
+	if err != nil {
+		return nil, cacheMetadata{}, err
+	}
+	return body, metadata, nil
+}
+
+// readMetadata returns the metadata of the cached body without reading it.
+func (c feedCache) readMetadata() (cacheMetadata, error) {
+	data, err := os.ReadFile(c.metadataPath)
+	if err != nil {
+		return cacheMetadata{}, err
+	}
+	var metadata cacheMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return cacheMetadata{}, err
+	}
+	return metadata, nil
+}
+
+// write stores a fetched body with its metadata; a nil body only refreshes
+// the metadata. The metadata is written last so a partial write is never
+// mistaken for a fresh cache entry.
+func (c feedCache) write(body []byte, metadata cacheMetadata) error {
+	if err := os.MkdirAll(filepath.Dir(c.bodyPath), 0o755); err != nil {
+		return err
+	}
+	if body != nil {
+		if err := os.WriteFile(c.bodyPath, body, 0o644); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.metadataPath, data, 0o644)
+}
+
+// fetchCached returns the feed body, served from the disk cache when it is
+// younger than -cache-ttl and fetched (then cached) otherwise. Every
+// successful fetch is cached, even with a zero -cache-ttl, so a failed fetch
+// can fall back to the last good copy, with a warning that it may be stale. Refetches send the cached
+// ETag, and a 304 Not Modified reuses the cached body. fromCache reports that
+// the body was served from the cache.
+func fetchCached(ctx context.Context, url string, opts options) (body []byte, fromCache bool, err error) {
+	cache, err := cacheFor(url)
+	if err != nil {
+		slog.Warn("Cache unavailable", "err", err)
+		resp, err := fetchWithRetry(ctx, url, opts.timeout, opts.retries, "", opts.watch > 0)
+		return resp.body, false, err
+	}
+
+	cached, metadata, cacheErr := cache.read()
+	if cacheErr == nil && opts.cacheTTL > 0 && time.Since(metadata.Fetched) < opts.cacheTTL {
+		slog.Debug("Serving feed from cache", "feed", url, "age", time.Since(metadata.Fetched).Round(time.Second))
+		return cached, true, nil
+	}
+
+	etag := ""
+	if cacheErr == nil {
+		etag = metadata.ETag
+	}
+
+	resp, err := fetchWithRetry(ctx, url, opts.timeout, opts.retries, etag, opts.watch > 0)
+	if err != nil {
+		var temporary temporaryError
+		if cacheErr != nil || ctx.Err() != nil || !errors.As(err, &temporary) {
+			return nil, false, err
+		}
+		slog.Warn("Fetch failed; using cached data, which may be stale", "feed", url, "age", time.Since(metadata.Fetched).Round(time.Second), "err", err)
+		return cached, true, nil
+	}
+
+	metadata = cacheMetadata{URL: url, Fetched: time.Now(), ETag: resp.etag}
+	if resp.notModified {
+		slog.Debug("Feed not modified, serving it from cache", "feed", url)
+		body, fromCache = cached, true
+		resp.body = nil
+	} else {
+		body = resp.body
+	}
+
+	if err := cache.write(resp.body, metadata); err != nil {
+		slog.Warn("Failed to update cache", "feed", url, "err", err)
+	}
+	return body, fromCache, nil
+}
+
+// openCached is fetchCached for streamQuakes: the body is returned unread, so
+// a fetched feed is decoded as it arrives rather than held in memory. It is
+// copied into the cache as it is read, the cache entry being replaced once
+// the body has been read to the end.
+func openCached(ctx context.Context, url string, opts options) (io.ReadCloser, error) {
+	cache, err := cacheFor(url)
+	if err != nil {
+		slog.Warn("Cache unavailable", "err", err)
+		stream, err := openWithRetry(ctx, url, opts.timeout, opts.retries, "", opts.watch > 0)
+		return stream.body, err
+	}
+
+	metadata, cacheErr := cache.readMetadata()
+	if cacheErr == nil && opts.cacheTTL > 0 && time.Since(metadata.Fetched) < opts.cacheTTL {
+		slog.Debug("Serving feed from cache", "feed", url, "age", time.Since(metadata.Fetched).Round(time.Second))
+		return os.Open(cache.bodyPath)
+	}
+
+	etag := ""
+	if cacheErr == nil {
+		etag = metadata.ETag
+	}
+
+	stream, err := openWithRetry(ctx, url, opts.timeout, opts.retries, etag, opts.watch > 0)
+	if err != nil {
+		var temporary temporaryError
+		if cacheErr != nil || ctx.Err() != nil || !errors.As(err, &temporary) {
+			return nil, err
+		}
+		slog.Warn("Fetch failed; using cached data, which may be stale", "feed", url, "age", time.Since(metadata.Fetched).Round(time.Second), "err", err)
+		return os.Open(cache.bodyPath)
+	}
+
+	metadata = cacheMetadata{URL: url, Fetched: time.Now(), ETag: stream.etag}
+	if stream.notModified {
+		slog.Debug("Feed not modified, serving it from cache", "feed", url)
+		if err := cache.write(nil, metadata); err != nil {
+			slog.Warn("Failed to update cache", "feed", url, "err", err)
+		}
+		return os.Open(cache.bodyPath)
+	}
+	return newCachingReader(stream.body, cache, metadata), nil
+}
+
+// cachingReader reads a fetched body while copying it into a temporary file,
+// which replaces the cached body when the reader is closed. A body that
+// could not be read in full leaves the cache as it was.
+type cachingReader struct {
+	body     io.ReadCloser
+	cache    feedCache
+	metadata cacheMetadata
+
+	// temp is nil when the copy could not be created or written
+	temp *os.File
+	// err is the first error reading body, other than io.EOF
+	err error
+}
+
+func newCachingReader(body io.ReadCloser, cache feedCache, metadata cacheMetadata) *cachingReader {
+	r := &cachingReader{body: body, cache: cache, metadata: metadata}
+
+	dir := filepath.Dir(cache.bodyPath)
+	err := os.MkdirAll(dir, 0o755)
+	if err == nil {
+		r.temp, err = os.CreateTemp(dir, "*.tmp")
+	}
+	if err != nil {
+		slog.Warn("Failed to update cache", "feed", metadata.URL, "err", err)
+	}
+	return r
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	if n > 0 && r.temp != nil {
+		if _, werr := r.temp.Write(p[:n]); werr != nil {
+			slog.Warn("Failed to update cache", "feed", r.metadata.URL, "err", werr)
+			r.discard()
+		}
+	}
+	return n, err
+}
+
+// Close reads whatever the decoder left, usually a trailing newline, so the
+// cache holds the whole body, then stores it.
+func (r *cachingReader) Close() error {
+	if r.err == nil && r.temp != nil {
+		io.Copy(io.Discard, r)
+	}
+	r.body.Close()
+	if r.temp == nil {
+		return nil
+	}
+	if r.err != nil {
+		r.discard()
+		return nil
+	}
+
+	err := r.temp.Close()
+	if err == nil {
+		err = os.Rename(r.temp.Name(), r.cache.bodyPath)
+	}
+	if err == nil {
+		err = r.cache.write(nil, r.metadata)
+	}
+	if err != nil {
+		os.Remove(r.temp.Name())
+		slog.Warn("Failed to update cache", "feed", r.metadata.URL, "err", err)
+	}
+	r.temp = nil
+	return nil
+}
+
+// discard drops the copy of the body.
+func (r *cachingReader) discard() {
+	r.temp.Close()
+	os.Remove(r.temp.Name())
+	r.temp = nil
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFetchCached(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+	}))
+	defer server.Close()
+
+	opts := options{timeout: defaultTimeout, cacheTTL: time.Hour}
+	for i := 0; i < 2; i++ {
+		if _, _, err := fetchCached(context.Background(), server.URL, opts); err != nil {
+			t.Fatalf("fetchCached() returned an error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the second fetch to be served from cache, got %d requests", calls)
+	}
+
+	// An expired entry is refreshed
+	cache, _ := cacheFor(server.URL)
+	_, metadata, _ := cache.read()
+	metadata.Fetched = time.Now().Add(-2 * time.Hour)
+	if err := cache.write(nil, metadata); err != nil {
+		t.Fatalf("Failed to age cache entry: %v", err)
+	}
+	if _, _, err := fetchCached(context.Background(), server.URL, opts); err != nil {
+		t.Fatalf("fetchCached() returned an error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected an expired cache entry to be refetched, got %d requests", calls)
+	}
+}
+
+func TestFetchCachedFallsBackWhenOffline(t *testing.T) {
+	// Store the original cache directory and retry delay
+	originalCacheDir := cacheDir
+	originalDelay := retryBaseDelay
+	defer func() {
+		cacheDir = originalCacheDir
+		retryBaseDelay = originalDelay
+	}()
+
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+	retryBaseDelay = time.Millisecond
+
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+	}))
+	defer server.Close()
+
+	opts := options{timeout: defaultTimeout, retries: 1}
+
+	// Without a cache, the failure is fatal
+	failing = true
+	if _, _, err := fetchCached(context.Background(), server.URL, opts); err == nil {
+		t.Fatal("fetchCached() expected an error without a cache, got nil")
+	}
+
+	failing = false
+	if _, _, err := fetchCached(context.Background(), server.URL, opts); err != nil {
+		t.Fatalf("fetchCached() returned an error: %v", err)
+	}
+
+	failing = true
+	body, fromCache, err := fetchCached(context.Background(), server.URL, opts)
+	if err != nil {
+		t.Fatalf("fetchCached() expected to fall back to the cache, got %v", err)
+	}
+	if !fromCache {
+		t.Error("Expected the fallback to report a cache hit")
+	}
+	if string(body) != `{"type": "FeatureCollection", "features": []}` {
+		t.Errorf("Expected cached body, got %q", body)
+	}
+}
+
+func TestFetchCachedETag(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	const feed = `{"type": "FeatureCollection", "features": []}`
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	opts := options{timeout: defaultTimeout}
+
+	body, fromCache, err := fetchCached(context.Background(), server.URL, opts)
+	if err != nil || fromCache || string(body) != feed {
+		t.Fatalf("First fetch: got %q, fromCache %v, err %v", body, fromCache, err)
+	}
+
+	body, fromCache, err = fetchCached(context.Background(), server.URL, opts)
+	if err != nil || !fromCache || string(body) != feed {
+		t.Fatalf("Second fetch: got %q, fromCache %v, err %v", body, fromCache, err)
+	}
+
+	if len(conditional) != 2 || conditional[0] != "" || conditional[1] != `"v1"` {
+		t.Errorf("Expected If-None-Match only on the second request, got %q", conditional)
+	}
+}
+
+func TestOpenCached(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	const feed = `{"type": "FeatureCollection", "features": []}` + "\n"
+	calls := 0
+	truncate := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("ETag", `"v1"`)
+		if truncate {
+			// Promise more than is sent, so the body ends early
+			w.Header().Set("Content-Length", "1000")
+			w.Write([]byte(`{"type": "Feat`))
+			return
+		}
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	// A body closed before it was read to the end is still cached whole
+	opts := options{timeout: defaultTimeout, cacheTTL: time.Hour}
+	r, err := openCached(context.Background(), server.URL, opts)
+	if err != nil {
+		t.Fatalf("openCached() returned an error: %v", err)
+	}
+	if _, err := r.Read(make([]byte, 5)); err != nil {
+		t.Fatalf("Failed to read the body: %v", err)
+	}
+	r.Close()
+
+	cache, _ := cacheFor(server.URL)
+	body, metadata, err := cache.read()
+	if err != nil || string(body) != feed || metadata.ETag != `"v1"` {
+		t.Fatalf("Expected the whole body cached with its ETag, got %q, %+v, %v", body, metadata, err)
+	}
+
+	// A fresh entry is read back from the cache
+	r, err = openCached(context.Background(), server.URL, opts)
+	if err != nil {
+		t.Fatalf("openCached() returned an error: %v", err)
+	}
+	body, _ = io.ReadAll(r)
+	r.Close()
+	if calls != 1 || string(body) != feed {
+		t.Errorf("Expected the cached body without a request, got %q after %d requests", body, calls)
+	}
+
+	// A body cut short leaves the cache as it was
+	truncate = true
+	opts.cacheTTL = 0
+	r, err = openCached(context.Background(), server.URL, opts)
+	if err != nil {
+		t.Fatalf("openCached() returned an error: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("Expected an error reading a truncated body")
+	}
+	r.Close()
+	if body, _, _ := cache.read(); string(body) != feed {
+		t.Errorf("Expected the truncated body not to replace the cache, got %q", body)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("Expected only the cached body and metadata to remain, got %d files", len(entries))
+	}
+}
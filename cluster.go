@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Default -cluster-radius and -cluster-window: aftershocks of a large event
+// mostly fall within about 100 km and the first few days.
+const (
+	defaultClusterRadius = 100.0
+	defaultClusterWindow = 72 * time.Hour
+)
+
+// quakeCluster is a group of linked earthquakes. mainshock is the strongest;
+// the others are counted as its aftershocks, in their original order.
+type quakeCluster struct {
+	mainshock   QuakeRecord
+	aftershocks []QuakeRecord
+}
+
+// clusterQuakes groups quakes by single linkage: two earthquakes within
+// radius km and window of each other share a cluster, and clusters sharing
+// an earthquake are merged. Earthquakes without a location stand alone.
+// Clusters are returned strongest mainshock first.
+func clusterQuakes(quakes []QuakeRecord, radius float64, window time.Duration) []quakeCluster {
+	// parent is a union-find forest over the indexes of quakes
+	parent := make([]int, len(quakes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i, a := range quakes {
+		if !a.hasLocation {
+			continue
+		}
+		for j := i + 1; j < len(quakes); j++ {
+			b := quakes[j]
+			if !b.hasLocation || absDuration(a.Time.Sub(b.Time)) > window {
+				continue
+			}
+			if haversine(a.Latitude, a.Longitude, b.Latitude, b.Longitude) <= radius {
+				parent[root(j)] = root(i)
+			}
+		}
+	}
+
+	index := make(map[int]int)
+	var members [][]QuakeRecord
+	for i, quake := range quakes {
+		r := root(i)
+		c, ok := index[r]
+		if !ok {
+			c = len(members)
+			index[r] = c
+			members = append(members, nil)
+		}
+		members[c] = append(members[c], quake)
+	}
+
+	clusters := make([]quakeCluster, 0, len(members))
+	for _, group := range members {
+		strongest := 0
+		for i, quake := range group {
+			if strongerThan(quake, group[strongest]) {
+				strongest = i
+			}
+		}
+		cluster := quakeCluster{mainshock: group[strongest]}
+		cluster.aftershocks = append(cluster.aftershocks, group[:strongest]...)
+		cluster.aftershocks = append(cluster.aftershocks, group[strongest+1:]...)
+		clusters = append(clusters, cluster)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return strongerThan(clusters[i].mainshock, clusters[j].mainshock)
+	})
+	return clusters
+}
+
+// strongerThan reports whether a has a greater magnitude than b; unknown
+// magnitudes are weaker than any known one.
+func strongerThan(a, b QuakeRecord) bool {
+	if a.unknownMag || b.unknownMag {
+		return !a.unknownMag && b.unknownMag
+	}
+	return a.Mag > b.Mag
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClusterQuakes(t *testing.T) {
+	origin := time.Date(2024, 1, 1, 7, 10, 0, 0, time.UTC)
+	quake := func(id string, mag, lat, lon float64, after time.Duration) QuakeRecord {
+		q := QuakeRecord{ID: id, Mag: mag, Time: origin.Add(after)}
+		q.setCoordinates([]float64{lon, lat, 10})
+		return q
+	}
+
+	quakes := []QuakeRecord{
+		quake("foreshock", 4.8, 37.50, 137.20, -time.Hour),
+		quake("main", 7.5, 37.49, 137.27, 0),
+		quake("after1", 5.2, 37.60, 137.40, 2*time.Hour),
+		// Linked through after1 rather than directly to the mainshock
+		quake("after2", 4.6, 38.20, 138.10, 3*time.Hour),
+		// Close by, but too late
+		quake("later", 4.5, 37.50, 137.25, 10*24*time.Hour),
+		// Same time, far away
+		quake("chile", 6.0, -33.45, -70.67, time.Hour),
+		{ID: "nowhere", unknownMag: true, Time: origin},
+	}
+
+	clusters := clusterQuakes(quakes, 100, 72*time.Hour)
+	if len(clusters) != 4 {
+		t.Fatalf("Expected 4 clusters, got %d: %+v", len(clusters), clusters)
+	}
+
+	expected := []struct {
+		mainshock   string
+		aftershocks []string
+	}{
+		{"main", []string{"foreshock", "after1", "after2"}},
+		{"chile", nil},
+		{"later", nil},
+		{"nowhere", nil},
+	}
+	for i, e := range expected {
+		c := clusters[i]
+		var ids []string
+		for _, q := range c.aftershocks {
+			ids = append(ids, q.ID)
+		}
+		if c.mainshock.ID != e.mainshock || strings.Join(ids, ",") != strings.Join(e.aftershocks, ",") {
+			t.Errorf("Cluster %d: expected %s with %v, got %s with %v", i, e.mainshock, e.aftershocks, c.mainshock.ID, ids)
+		}
+	}
+}
+
+func TestPrintQuakesCluster(t *testing.T) {
+	a := QuakeRecord{Place: "Mainshock", Mag: 7}
+	a.setCoordinates([]float64{137, 37, 10})
+	b := QuakeRecord{Place: "Aftershock", Mag: 5}
+	b.setCoordinates([]float64{137.1, 37.1, 10})
+
+	var buf bytes.Buffer
+	opts := options{location: time.UTC, cluster: true, clusterRadius: defaultClusterRadius, clusterWindow: defaultClusterWindow}
+	printQuakes(&buf, Metadata{}, []QuakeRecord{b, a}, 2, opts)
+
+	if !strings.Contains(buf.String(), "Cluster of 2 earthquakes: mainshock and 1 aftershock\n") {
+		t.Errorf("Expected a cluster header, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Epicenter = Mainshock") || strings.Contains(buf.String(), "Epicenter = Aftershock") {
+		t.Errorf("Expected only the mainshock to be printed, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// Values accepted by the -color flag.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// ANSI escape sequences used to highlight magnitudes.
+const (
+	ansiReset  = "\033[0m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiOrange = "\033[38;5;208m"
+	ansiRed    = "\033[31m"
+)
+
+// useColor resolves a -color mode for the given output; auto colors only
+// when it is a terminal.
+func useColor(mode string, out io.Writer) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// severity is the band a magnitude falls in, shared by the terminal, TUI and
+// Slack colors.
+type severity int
+
+const (
+	severityMinor severity = iota
+	severityModerate
+	severityStrong
+	severityMajor
+)
+
+// magnitudeSeverity bands a magnitude: below 3, from 3, from 5 and from 7.
+func magnitudeSeverity(mag float64) severity {
+	switch {
+	case mag >= 7:
+		return severityMajor
+	case mag >= 5:
+		return severityStrong
+	case mag >= 3:
+		return severityModerate
+	default:
+		return severityMinor
+	}
+}
+
+// ansiSeverityColors is the escape sequence for each severity.
+var ansiSeverityColors = [...]string{
+	severityMinor:    ansiGreen,
+	severityModerate: ansiYellow,
+	severityStrong:   ansiOrange,
+	severityMajor:    ansiRed,
+}
+
+// magnitudeColor picks the escape sequence for a magnitude's severity.
+func magnitudeColor(mag float64) string {
+	return ansiSeverityColors[magnitudeSeverity(mag)]
+}
+
+// colorize wraps s in the color for mag when enabled is true.
+func colorize(s string, mag float64, enabled bool) string {
+	if !enabled {
+		return s
+	}
+	return magnitudeColor(mag) + s + ansiReset
+}
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestColorize(t *testing.T) {
+	tests := []struct {
+		mag      float64
+		expected string
+	}{
+		{2.9, ansiGreen},
+		{3.0, ansiYellow},
+		{5.0, ansiOrange},
+		{6.9, ansiOrange},
+		{7.0, ansiRed},
+	}
+
+	for _, tt := range tests {
+		if got := colorize("x", tt.mag, true); got != tt.expected+"x"+ansiReset {
+			t.Errorf("colorize(%v) = %q", tt.mag, got)
+		}
+	}
+
+	if got := colorize("x", 7.5, false); got != "x" {
+		t.Errorf("Expected no escapes when disabled, got %q", got)
+	}
+}
+
+func TestMagnitudeSeverity(t *testing.T) {
+	tests := []struct {
+		mag      float64
+		expected severity
+	}{
+		{-1, severityMinor},
+		{2.9, severityMinor},
+		{3, severityModerate},
+		{4.9, severityModerate},
+		{5, severityStrong},
+		{7, severityMajor},
+		{9.1, severityMajor},
+	}
+
+	for _, tt := range tests {
+		if got := magnitudeSeverity(tt.mag); got != tt.expected {
+			t.Errorf("magnitudeSeverity(%v): expected %d, got %d", tt.mag, tt.expected, got)
+		}
+	}
+}
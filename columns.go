@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// column is a field of an earthquake: name selects it with -columns and
+// -sort, header labels it in CSV and title in -format table. field is the
+// QuakeRecord field a -template reads it from; about and example describe it
+// for -list-fields.
+type column struct {
+	name    string
+	header  string
+	title   string
+	field   string
+	about   string
+	example string
+	value   func(QuakeRecord) string
+}
+
+// quakeColumns lists every field of an earthquake, in -list-fields order.
+var quakeColumns = []column{
+	{"id", "id", "ID", "ID", "USGS event ID", "us7000abcd", func(q QuakeRecord) string { return q.ID }},
+	{"place", "place", "Place", "Place", "description of the epicenter", "10 km SSW of Ridgecrest, CA", func(q QuakeRecord) string { return q.Place }},
+	{"mag", "magnitude", "Mag", "Mag", "magnitude, empty when unknown", "5.4", QuakeRecord.magField},
+	{"magtype", "magnitude_type", "Type", "MagType", "magnitude scale", "mww", func(q QuakeRecord) string { return q.MagType }},
+	{"time", "time_utc", "Time", "Time", "origin time", "2024-01-02T03:04:05Z", QuakeRecord.timeField},
+	{"updated", "updated_utc", "Updated", "Updated", "time of the latest revision", "2024-01-02T04:00:00Z", func(q QuakeRecord) string { return q.Updated.Format(time.RFC3339) }},
+	{"longitude", "longitude", "Lon", "Longitude", "epicenter longitude in degrees", "-117.6", func(q QuakeRecord) string { longitude, _ := q.coordinateFields(); return longitude }},
+	{"latitude", "latitude", "Lat", "Latitude", "epicenter latitude in degrees", "35.7", func(q QuakeRecord) string { _, latitude := q.coordinateFields(); return latitude }},
+	{"depth", "depth", "Depth", "Depth", "hypocenter depth in km", "10.5", QuakeRecord.depthField},
+	{"distance", "distance_km", "Distance", "Distance", "distance from -near in km", "42.0", func(q QuakeRecord) string {
+		if q.Distance == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*q.Distance, 'f', 1, 64)
+	}},
+	{"sig", "significance", "Sig", "Sig", "USGS significance, 0 to about 1000", "449", func(q QuakeRecord) string { return strconv.Itoa(q.Sig) }},
+	{"felt", "felt", "Felt", "Felt", "number of felt reports, empty when none", "12", func(q QuakeRecord) string {
+		if q.Felt == nil {
+			return ""
+		}
+		return strconv.Itoa(*q.Felt)
+	}},
+	{"tsunami", "tsunami", "Tsunami", "Tsunami", "whether a tsunami is possible", "no", func(q QuakeRecord) string { return yesNo(q.Tsunami) }},
+	{"alert", "alert", "Alert", "Alert", "PAGER alert level", "green", func(q QuakeRecord) string { return q.Alert }},
+	{"status", "status", "Status", "Status", "review status: " + strings.Join(reviewStatuses, ", "), "reviewed", func(q QuakeRecord) string { return q.Status }},
+	{"net", "network", "Net", "Net", "seismic network that located it", "ci", func(q QuakeRecord) string { return q.Net }},
+	{"url", "url", "URL", "URL", "USGS event page", "https://earthquake.usgs.gov/earthquakes/eventpage/us7000abcd", func(q QuakeRecord) string { return q.URL }},
+}
+
+// Default -columns for -format csv and -format table.
+const (
+	defaultColumns      = "place,mag,time,longitude,latitude,depth"
+	defaultTableColumns = "place,mag,depth,time"
+)
+
+// parseColumns resolves a comma-separated -columns list, in the order given,
+// or returns an error listing the valid names.
+func parseColumns(spec string) ([]column, error) {
+	var columns []column
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, c := range quakeColumns {
+			if c.name == name {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(quakeColumns))
+			for i, c := range quakeColumns {
+				names[i] = c.name
+			}
+			return nil, fmt.Errorf("unknown column %q (valid columns: %s)", name, strings.Join(names, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// columnTitles returns the -format table header row for columns.
+func columnTitles(columns []column) []string {
+	titles := make([]string, len(columns))
+	for i, c := range columns {
+		titles[i] = c.title
+	}
+	return titles
+}
+
+// columnHeaders returns the header row for columns.
+func columnHeaders(columns []column) []string {
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.header
+	}
+	return headers
+}
+
+// columnValues renders quake as a row of columns.
+func columnValues(quake QuakeRecord, columns []column) []string {
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = c.value(quake)
+	}
+	return values
+}
+
+// writeFields lists every field with the -columns, -sort, -filter and
+// -template names it goes by, a description and an example value, for
+// -list-fields.
+func writeFields(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	io.WriteString(tw, "FIELD\tSORT\tFILTER\tTEMPLATE\tDESCRIPTION\tEXAMPLE\n")
+	for _, c := range quakeColumns {
+		sortable, filterable := "-", "-"
+		if _, ok := quakeLess[c.name]; ok {
+			sortable = "yes"
+		}
+		if f, ok := filterFields[c.name]; ok {
+			filterable = f.kind.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t.%s\t%s\t%s\n", c.name, sortable, filterable, c.field, c.about, c.example)
+	}
+
+	return tw.Flush()
+}
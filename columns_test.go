@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustParseColumns is parseColumns for specs the test knows to be valid.
+func mustParseColumns(t *testing.T, spec string) []column {
+	t.Helper()
+	columns, err := parseColumns(spec)
+	if err != nil {
+		t.Fatalf("parseColumns(%q) returned an error: %v", spec, err)
+	}
+	return columns
+}
+
+func TestParseColumns(t *testing.T) {
+	columns := mustParseColumns(t, "Place, mag,depth,time")
+	if got := strings.Join(columnHeaders(columns), ","); got != "place,magnitude,depth,time_utc" {
+		t.Errorf("Expected the columns in the order given, got %s", got)
+	}
+
+	if got := strings.Join(columnHeaders(mustParseColumns(t, defaultColumns)), ","); got != "place,magnitude,time_utc,longitude,latitude,depth" {
+		t.Errorf("Expected the default columns to keep the original CSV header, got %s", got)
+	}
+
+	_, err := parseColumns("place,magnitude")
+	if err == nil || !strings.Contains(err.Error(), `"magnitude"`) || !strings.Contains(err.Error(), "magtype") {
+		t.Errorf("Expected an error naming the column and listing the valid ones, got %v", err)
+	}
+}
+
+func TestWriteCSVColumns(t *testing.T) {
+	felt, distance := 12, 42.04
+	quake := QuakeRecord{
+		ID:       "us1000abcd",
+		Place:    "Somewhere",
+		Mag:      4.5,
+		Time:     time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+		Felt:     &felt,
+		Distance: &distance,
+		Tsunami:  true,
+	}
+	quake.setCoordinates([]float64{-117.5, 35.25, 8.1})
+
+	var buf bytes.Buffer
+	columns := mustParseColumns(t, "id,mag,depth,felt,distance,tsunami,alert")
+	if err := writeCSV(&buf, []QuakeRecord{quake, {ID: "bare", unknownMag: true}}, columns); err != nil {
+		t.Fatalf("writeCSV() returned an error: %v", err)
+	}
+
+	expected := "id,magnitude,depth,felt,distance_km,tsunami,alert\n" +
+		"us1000abcd,4.5,8.1,12,42.0,yes,\n" +
+		"bare,,,,,no,\n"
+	if buf.String() != expected {
+		t.Errorf("Expected CSV output %q, got %q", expected, buf.String())
+	}
+}
+
+func TestQuakeColumnsCoverQuakeRecord(t *testing.T) {
+	// Every exported field is listed once, under its own name
+	fields := map[string]bool{}
+	for _, c := range quakeColumns {
+		if _, ok := reflect.TypeOf(QuakeRecord{}).FieldByName(c.field); !ok {
+			t.Errorf("Column %s names a missing QuakeRecord field %q", c.name, c.field)
+		}
+		if fields[c.field] {
+			t.Errorf("Expected field %s to be listed once", c.field)
+		}
+		fields[c.field] = true
+		if c.about == "" || c.example == "" {
+			t.Errorf("Expected column %s to be described, got %q, %q", c.name, c.about, c.example)
+		}
+	}
+	for _, f := range reflect.VisibleFields(reflect.TypeOf(QuakeRecord{})) {
+		if f.IsExported() && !fields[f.Name] {
+			t.Errorf("Expected QuakeRecord field %s to have a column", f.Name)
+		}
+	}
+
+	for _, key := range sortKeys {
+		if _, err := parseColumns(key); err != nil {
+			t.Errorf("Expected sort key %s to be a column, got %v", key, err)
+		}
+	}
+}
+
+func TestWriteFields(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFields(&buf); err != nil {
+		t.Fatalf("writeFields() returned an error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(quakeColumns)+1 {
+		t.Fatalf("Expected a header and %d fields, got %q", len(quakeColumns), buf.String())
+	}
+	if got := strings.Fields(lines[0]); strings.Join(got, " ") != "FIELD SORT FILTER TEMPLATE DESCRIPTION EXAMPLE" {
+		t.Errorf("Expected the header row, got %q", lines[0])
+	}
+
+	tests := map[string]string{
+		"mag":     "mag yes number .Mag magnitude, empty when unknown 5.4",
+		"place":   "place - text .Place description of the epicenter",
+		"tsunami": "tsunami - boolean .Tsunami whether a tsunami is possible",
+		"updated": "updated - time .Updated time of the latest revision",
+	}
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if expected, ok := tests[fields[0]]; ok && !strings.HasPrefix(strings.Join(fields, " "), expected) {
+			t.Errorf("Expected %q, got %q", expected, line)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Shells -completion writes a script for.
+const (
+	shellBash = "bash"
+	shellZsh  = "zsh"
+	shellFish = "fish"
+)
+
+// completionShells lists the -completion choices.
+var completionShells = []string{shellBash, shellZsh, shellFish}
+
+// hiddenFlags are left out of the usage text and of completions.
+var hiddenFlags = map[string]bool{"completion": true}
+
+// fileFlags take a path, completed from the file system.
+var fileFlags = map[string]bool{"file": true, "out": true, "template": true, "state": true, "db": true, "cacert": true, "config": true}
+
+// flagChoices returns the values a flag accepts, for the flags with a fixed
+// set. They come from the same lists the flags are validated against.
+func flagChoices(name string) []string {
+	switch name {
+	case "feed":
+		return feedNames()
+	case "days":
+		return feedDayChoices()
+	case "class":
+		return feedClasses
+	case "format":
+		return outputFormats
+	case "sort":
+		return sortKeys
+	case "region":
+		return regionNames()
+	case "units":
+		return []string{unitsMetric, unitsImperial}
+	case "coords":
+		return []string{coordinatesDecimal, coordinatesDMS}
+	case "color":
+		return []string{colorAuto, colorAlways, colorNever}
+	case "status":
+		return reviewStatuses
+	case "log-format":
+		return []string{logFormatText, logFormatJSON}
+	}
+	return nil
+}
+
+// completionFlag is a flag as a completion script describes it.
+type completionFlag struct {
+	name        string
+	description string
+	boolean     bool
+}
+
+// completionFlags lists the visible flags of fs in name order.
+func completionFlags(fs *flag.FlagSet) []completionFlag {
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if hiddenFlags[f.Name] {
+			return
+		}
+		boolean := false
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+			boolean = b.IsBoolFlag()
+		}
+		flags = append(flags, completionFlag{name: f.Name, description: f.Usage, boolean: boolean})
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].name < flags[j].name })
+	return flags
+}
+
+// printVisibleDefaults is fs.PrintDefaults without the hidden flags.
+func printVisibleDefaults(fs *flag.FlagSet) {
+	visible := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	visible.SetOutput(fs.Output())
+	fs.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+			// The value may already be parsed; show the real default
+			visible.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	visible.PrintDefaults()
+}
+
+// completionScript returns the completion script for shell, covering the
+// flags of fs and the values of those with a fixed set.
+func completionScript(shell string, fs *flag.FlagSet) (string, error) {
+	var b strings.Builder
+	flags := completionFlags(fs)
+	switch shell {
+	case shellBash:
+		writeBashCompletion(&b, flags)
+	case shellZsh:
+		writeZshCompletion(&b, flags)
+	case shellFish:
+		writeFishCompletion(&b, flags)
+	default:
+		return "", fmt.Errorf("unknown shell %q for -completion (valid shells: %s)", shell, strings.Join(completionShells, ", "))
+	}
+	return b.String(), nil
+}
+
+// writeBashCompletion writes a function for bash's complete -F.
+func writeBashCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "# bash completion for eqk; load with: source <(eqk -completion bash)")
+	fmt.Fprintln(w, "_eqk() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `	case "$prev" in`)
+	for _, f := range flags {
+		switch {
+		case fileFlags[f.name]:
+			fmt.Fprintf(w, "\t-%s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", f.name)
+		case flagChoices(f.name) != nil:
+			fmt.Fprintf(w, "\t-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.name, strings.Join(flagChoices(f.name), " "))
+		case !f.boolean:
+			// Any value; offer nothing rather than flag names
+			fmt.Fprintf(w, "\t-%s) return ;;\n", f.name)
+		}
+	}
+	fmt.Fprintln(w, "\tesac")
+
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "-" + f.name
+	}
+	fmt.Fprintf(w, "\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _eqk eqk")
+}
+
+// zshEscaper escapes a flag description for an _arguments spec in single
+// quotes.
+var zshEscaper = strings.NewReplacer(`'`, `'\''`, "[", `\[`, "]", `\]`, ":", `\:`)
+
+// writeZshCompletion writes an _arguments based completion function.
+func writeZshCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "#compdef eqk")
+	fmt.Fprintln(w, "# zsh completion for eqk; save as _eqk in a directory on $fpath")
+	fmt.Fprintln(w, "_arguments \\")
+	for _, f := range flags {
+		spec := "-" + f.name + "[" + zshEscaper.Replace(f.description) + "]"
+		switch {
+		case fileFlags[f.name]:
+			spec += ":file:_files"
+		case flagChoices(f.name) != nil:
+			spec += ":" + f.name + ":(" + strings.Join(flagChoices(f.name), " ") + ")"
+		case !f.boolean:
+			spec += ":" + f.name + ": "
+		}
+		fmt.Fprintf(w, "  '%s' \\\n", spec)
+	}
+	fmt.Fprintln(w, "  '*:minimum magnitude: '")
+}
+
+// fishEscaper escapes a flag description for fish in single quotes.
+var fishEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// writeFishCompletion writes a complete command per flag.
+func writeFishCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "# fish completion for eqk; load with: eqk -completion fish | source")
+	fmt.Fprintln(w, "complete -c eqk -f")
+	for _, f := range flags {
+		line := fmt.Sprintf("complete -c eqk -o %s -d '%s'", f.name, fishEscaper.Replace(f.description))
+		switch {
+		case fileFlags[f.name]:
+			line += " -r -F"
+		case flagChoices(f.name) != nil:
+			line += " -x -a '" + strings.Join(flagChoices(f.name), " ") + "'"
+		case !f.boolean:
+			line += " -x"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScript(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+		hidden   string
+	}{
+		{shellBash, []string{"complete -F _eqk eqk", `-feed) COMPREPLY=($(compgen -W "significant_hour`, `-file) COMPREPLY=($(compgen -f`, " -verbose "}, "-completion)"},
+		{shellZsh, []string{"#compdef eqk", "'-units[", ":units:(metric imperial)'", ":file:_files'", "proxy'\\''s]"}, "'-completion["},
+		{shellFish, []string{"complete -c eqk -o sort", "-x -a 'mag time depth'", "-o out -d 'write the report to this file instead of stdout' -r -F", "proxy\\'s"}, "-o completion "},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseFlags([]string{"-completion", tt.shell})
+		if err != nil {
+			t.Fatalf("parseFlags(%s) returned an error: %v", tt.shell, err)
+		}
+		for _, want := range tt.expected {
+			if !strings.Contains(opts.completion, want) {
+				t.Errorf("Expected the %s script to contain %q, got:\n%s", tt.shell, want, opts.completion)
+			}
+		}
+		if strings.Contains(opts.completion, tt.hidden) {
+			t.Errorf("Expected the %s script to leave out -completion", tt.shell)
+		}
+		if !strings.Contains(opts.completion, "all_month") || !strings.Contains(opts.completion, formatTable) {
+			t.Errorf("Expected the %s script to list every feed and format", tt.shell)
+		}
+	}
+
+	if _, err := parseFlags([]string{"-completion", "powershell"}); err == nil {
+		t.Error("Expected an error for an unknown shell")
+	}
+}
+
+func TestUsageHidesCompletion(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := parseArgs([]string{"-h"}, &buf, true); err == nil {
+		t.Fatal("Expected -h to return an error")
+	}
+	if strings.Contains(buf.String(), "-completion") {
+		t.Errorf("Expected -completion to be hidden, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "-feed string\n") || !strings.Contains(buf.String(), `(default "significant_month")`) {
+		t.Errorf("Expected the other flags with their defaults, got:\n%s", buf.String())
+	}
+}
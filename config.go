@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configPath returns the config file read when -config is not given; it is a
+// variable so tests can point it elsewhere.
+var configPath = func() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "eqk", "config.yaml"), nil
+}
+
+// unconfigurableFlags only make sense on the command line.
+var unconfigurableFlags = map[string]bool{"config": true, "completion": true, "list-fields": true, "version": true}
+
+// environmentFlags are the flags that can default to an environment
+// variable, by variable name; these override the config file.
+var environmentFlags = map[string]string{
+	"EQK_MIN":      "min",
+	"EQK_FEED":     "feed",
+	"EQK_FORMAT":   "format",
+	"EQK_TIMEZONE": "tz",
+	"EQK_URL":      "url",
+}
+
+// Where an option was set, from weakest to strongest. When options that
+// choose between each other, like -feed and -days, come from different
+// layers, the stronger one wins.
+const (
+	defaultLayer = iota
+	configLayer
+	environmentLayer
+	commandLineLayer
+)
+
+// optionLayers records the layer of each option set by the config file or
+// the environment.
+type optionLayers map[string]int
+
+// of returns the layer the named flag was set in.
+func (l optionLayers) of(flags *flag.FlagSet, name string) int {
+	if isFlagSet(flags, name) {
+		return commandLineLayer
+	}
+	return l[name]
+}
+
+// configArg returns the -config value among args, which have not been parsed
+// yet, accepting the same -config path and -config=path forms as flag and
+// stopping at --.
+func configArg(args []string) (path string, ok bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"), "=")
+		if name != "config" {
+			continue
+		}
+		if hasValue {
+			return value, true
+		}
+		if i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// loadConfig reads the options in the -config file named in args, which must
+// exist, or else in the default config file, if there is one. It returns
+// them by flag name along with the file they came from.
+func loadConfig(args []string) (map[string]string, string, error) {
+	path, explicit := configArg(args)
+	if !explicit {
+		var err error
+		if path, err = configPath(); err != nil {
+			// No config directory, so no default config file either
+			return nil, "", nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if !explicit && errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, "", fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case nil:
+			return nil, "", fmt.Errorf("invalid config file %s: %s has no value", path, name)
+		case []any:
+			// A list, e.g. of feeds, is the comma-separated form of the flag
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case map[string]any:
+			return nil, "", fmt.Errorf("invalid config file %s: %s must be a single value or a list", path, name)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, path, nil
+}
+
+// applyDefaults makes values the defaults of the flags they name, recording
+// them in layers at layer, so flags given on the command line still override
+// them and count as the only ones set. source names where the values came
+// from in errors.
+func applyDefaults(flags *flag.FlagSet, values map[string]string, source string, layers optionLayers, layer int) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flags.Lookup(name)
+		if f == nil || unconfigurableFlags[name] {
+			return fmt.Errorf("unknown option %q in %s", name, source)
+		}
+		if err := f.Value.Set(values[name]); err != nil {
+			return fmt.Errorf("invalid value %q for %s in %s: %v", values[name], name, source, err)
+		}
+		f.DefValue = f.Value.String()
+		layers[name] = layer
+	}
+	return nil
+}
+
+// applyEnvironment makes the environmentFlags variables that are set and not
+// empty the defaults of their flags, as applyDefaults does for the config
+// file.
+func applyEnvironment(flags *flag.FlagSet, layers optionLayers) error {
+	names := make([]string, 0, len(environmentFlags))
+	for name := range environmentFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if err := applyDefaults(flags, map[string]string{environmentFlags[name]: value}, name, layers, environmentLayer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
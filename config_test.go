@@ -0,0 +1,254 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the developer's own config file and environment out of the tests
+	configPath = func() (string, error) { return filepath.Join(os.TempDir(), "eqk-test-missing", "config.yaml"), nil }
+	for name := range environmentFlags {
+		os.Unsetenv(name)
+	}
+	os.Exit(m.Run())
+}
+
+// writeConfig writes a config file for a test and returns its path.
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigArg(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		ok       bool
+	}{
+		{[]string{"-min", "5", "-config", "a.yaml"}, "a.yaml", true},
+		{[]string{"--config=b.yaml"}, "b.yaml", true},
+		{[]string{"-config"}, "", false},
+		{[]string{"-min", "5"}, "", false},
+		{[]string{"--", "-config", "d.yaml"}, "", false},
+	}
+
+	for _, tt := range tests {
+		path, ok := configArg(tt.args)
+		if path != tt.expected || ok != tt.ok {
+			t.Errorf("configArg(%q): expected %q, %v, got %q, %v", tt.args, tt.expected, tt.ok, path, ok)
+		}
+	}
+}
+
+func TestParseFlagsConfig(t *testing.T) {
+	path := writeConfig(t, `
+min: 4.5
+feed: [4.5_week, significant_month]
+format: table
+tz: Asia/Tokyo
+out: report.txt
+`)
+
+	opts, err := parseFlags([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.minimumMagnitude != 4.5 || opts.feed != "4.5_week,significant_month" || len(opts.feedURLs) != 2 || opts.format != formatTable || opts.outputPath != "report.txt" {
+		t.Errorf("Expected the config file's options, got %+v", opts)
+	}
+	if opts.location.String() != "Asia/Tokyo" {
+		t.Errorf("Expected the config file's time zone, got %s", opts.location)
+	}
+
+	// Flags, the positional magnitude and -days still override the file
+	opts, err = parseFlags([]string{"-config", path, "-format", "csv", "-days", "1", "6"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.minimumMagnitude != 6 || opts.format != formatCSV || opts.feed != "significant_day" {
+		t.Errorf("Expected the command line to win, got min %v, format %s, feed %s", opts.minimumMagnitude, opts.format, opts.feed)
+	}
+}
+
+func TestParseFlagsDefaultConfig(t *testing.T) {
+	originalPath := configPath
+	defer func() { configPath = originalPath }()
+
+	path := writeConfig(t, "format: json\n")
+	configPath = func() (string, error) { return path, nil }
+	opts, err := parseFlags(nil)
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.format != formatJSON {
+		t.Errorf("Expected the default config file to be read, got format %s", opts.format)
+	}
+
+	configPath = func() (string, error) { return filepath.Join(t.TempDir(), "missing.yaml"), nil }
+	if _, err := parseFlags(nil); err != nil {
+		t.Errorf("Expected a missing default config file to be ignored, got %v", err)
+	}
+}
+
+func TestParseFlagsConfigErrors(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected string
+	}{
+		{"magnitude: 5\n", `unknown option "magnitude"`},
+		{"version: true\n", `unknown option "version"`},
+		{"min: strong\n", `invalid value "strong" for min`},
+		{"feed:\n  class: 4.5\n", "must be a single value or a list"},
+		{"feed:\n", "feed has no value"},
+		{"- min\n", "invalid config file"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseFlags([]string{"-config", writeConfig(t, tt.config)})
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Config %q: expected an error containing %q, got %v", tt.config, tt.expected, err)
+		}
+	}
+
+	if _, err := parseFlags([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("Expected an error for a missing -config file")
+	}
+}
+
+func TestParseFlagsEnvironment(t *testing.T) {
+	t.Setenv("EQK_MIN", "5.5")
+	t.Setenv("EQK_FEED", "all_week")
+	t.Setenv("EQK_FORMAT", "csv")
+	t.Setenv("EQK_TIMEZONE", "Asia/Tokyo")
+
+	opts, err := parseFlags(nil)
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.minimumMagnitude != 5.5 || opts.feed != "all_week" || opts.format != formatCSV || opts.location.String() != "Asia/Tokyo" {
+		t.Errorf("Expected the environment's options, got min %v, feed %s, format %s, tz %s", opts.minimumMagnitude, opts.feed, opts.format, opts.location)
+	}
+
+	// The environment overrides the config file, and flags override both
+	path := writeConfig(t, "min: 3\nformat: table\nsort: mag\n")
+	opts, err = parseFlags([]string{"-config", path, "-format", "json"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.minimumMagnitude != 5.5 || opts.format != formatJSON || opts.sort != "mag" {
+		t.Errorf("Expected flag > environment > config file, got min %v, format %s, sort %s", opts.minimumMagnitude, opts.format, opts.sort)
+	}
+
+	t.Setenv("EQK_MIN", "strong")
+	if _, err := parseFlags(nil); err == nil || !strings.Contains(err.Error(), "EQK_MIN") {
+		t.Errorf("Expected an error naming EQK_MIN, got %v", err)
+	}
+}
+
+func TestParseFlagsEnvironmentURL(t *testing.T) {
+	t.Setenv("EQK_URL", "https://example.com/quakes.geojson")
+	t.Setenv("EQK_FEED", "all_week")
+
+	opts, err := parseFlags(nil)
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.url != "https://example.com/quakes.geojson" || opts.feed != "" {
+		t.Errorf("Expected EQK_URL to be fetched, got url %s, feed %s", opts.url, opts.feed)
+	}
+
+	// A feed or file on the command line replaces EQK_URL
+	opts, err = parseFlags([]string{"-feed", "significant_day"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if !strings.Contains(opts.url, "significant_day") {
+		t.Errorf("Expected -feed to override EQK_URL, got %s", opts.url)
+	}
+	opts, err = parseFlags([]string{"-file", "quakes.geojson"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.url != "" || opts.inputPath != "quakes.geojson" {
+		t.Errorf("Expected -file to override EQK_URL, got url %s, file %s", opts.url, opts.inputPath)
+	}
+}
+
+func TestParseFlagsConfigFeedSelection(t *testing.T) {
+	tests := []struct {
+		config   string
+		env      string
+		args     []string
+		expected string
+	}{
+		{"days: 7\nclass: \"4.5\"\n", "", nil, "4.5_week"},
+		{"days: 7\nclass: \"4.5\"\nfeed: all_day\n", "", nil, "4.5_week"},
+		{"days: 7\n", "", []string{"-feed", "all_day"}, "all_day"},
+		{"feed: all_day\n", "", []string{"-days", "30"}, "significant_month"},
+		{"days: 7\n", "all_hour", nil, "all_hour"},
+		{"file: quakes.geojson\n", "", []string{"-feed", "all_day"}, "all_day"},
+		{"url: https://example.com/a.geojson\nfeed: all_day\n", "", nil, "url https://example.com/a.geojson"},
+		{"url: https://example.com/a.geojson\n", "", []string{"-class", "2.5"}, "2.5_month"},
+		{"feed: all_day\n", "", []string{"-file", "quakes.geojson"}, "file quakes.geojson"},
+		{"file: quakes.geojson\n", "", []string{"-"}, "file -"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("EQK_FEED", tt.env)
+			}
+			opts, err := parseFlags(append([]string{"-config", writeConfig(t, tt.config)}, tt.args...))
+			if err != nil {
+				t.Fatalf("parseFlags() returned an error: %v", err)
+			}
+			got := opts.feed
+			if opts.inputPath != "" {
+				got = "file " + opts.inputPath
+			} else if opts.feed == "" {
+				got = "url " + opts.url
+			}
+			if got != tt.expected {
+				t.Errorf("Config %q with %q: expected %s, got %s", tt.config, tt.args, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseFlagsConfigFormat(t *testing.T) {
+	opts, err := parseFlags([]string{"-config", writeConfig(t, "format: table\ncolumns: id,mag\n")})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if got := strings.Join(columnHeaders(opts.columns), ","); got != "id,magnitude" {
+		t.Errorf("Expected the config file's table columns, got %s", got)
+	}
+
+	template := filepath.Join(t.TempDir(), "quake.tmpl")
+	if err := os.WriteFile(template, []byte("{{.Place}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := writeConfig(t, "template: "+template+"\njson: true\n")
+	for _, tt := range []struct {
+		args     []string
+		expected string
+	}{
+		{nil, formatTemplate},
+		{[]string{"-format", "csv"}, formatCSV},
+	} {
+		opts, err := parseFlags(append([]string{"-config", path}, tt.args...))
+		if err != nil {
+			t.Fatalf("parseFlags(%q) returned an error: %v", tt.args, err)
+		}
+		if opts.format != tt.expected {
+			t.Errorf("parseFlags(%q): expected format %s, got %s", tt.args, tt.expected, opts.format)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+
+	// Registers the "sqlite" driver, a pure Go port that needs no cgo
+	_ "modernc.org/sqlite"
+)
+
+// dbSchema creates the -db table. time and updated are milliseconds since
+// the epoch, as in the feed; mag and depth are NULL when unknown.
+const dbSchema = `CREATE TABLE IF NOT EXISTS earthquakes (
+	id      TEXT PRIMARY KEY,
+	place   TEXT NOT NULL,
+	mag     REAL,
+	depth   REAL,
+	time    INTEGER NOT NULL,
+	updated INTEGER NOT NULL,
+	lon     REAL,
+	lat     REAL
+)`
+
+// dbUpsert inserts an earthquake, or replaces the stored copy when this one
+// has a later revision time, so corrected magnitudes overwrite old ones.
+const dbUpsert = `INSERT INTO earthquakes (id, place, mag, depth, time, updated, lon, lat)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+	place = excluded.place, mag = excluded.mag, depth = excluded.depth, time = excluded.time,
+	updated = excluded.updated, lon = excluded.lon, lat = excluded.lat
+WHERE excluded.updated > earthquakes.updated`
+
+// saveQuakes upserts quakes into the SQLite database at path, creating it
+// and its table as needed. Earthquakes without an ID cannot be keyed and are
+// skipped.
+func saveQuakes(path string, quakes []QuakeRecord) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(dbSchema); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(dbUpsert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, quake := range quakes {
+		if quake.ID == "" {
+			continue
+		}
+
+		var mag, depth, lon, lat sql.NullFloat64
+		if !quake.unknownMag {
+			mag = sql.NullFloat64{Float64: quake.Mag, Valid: true}
+		}
+		if quake.hasDepth {
+			depth = sql.NullFloat64{Float64: quake.Depth, Valid: true}
+		}
+		if quake.hasLocation {
+			lon = sql.NullFloat64{Float64: quake.Longitude, Valid: true}
+			lat = sql.NullFloat64{Float64: quake.Latitude, Valid: true}
+		}
+
+		if _, err := stmt.Exec(quake.ID, quake.Place, mag, depth, quake.Time.UnixMilli(), quake.Updated.UnixMilli(), lon, lat); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
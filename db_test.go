@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveQuakes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quakes.db")
+	origin := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	quake := QuakeRecord{ID: "a", Place: "First", Mag: 5.1, Time: origin, Updated: origin.Add(time.Hour)}
+	quake.setCoordinates([]float64{142.1, 38.3, 10})
+	unknown := QuakeRecord{ID: "b", Place: "Unknown", unknownMag: true, Time: origin, Updated: origin}
+	if err := saveQuakes(path, []QuakeRecord{quake, unknown, {Place: "No ID"}}); err != nil {
+		t.Fatalf("saveQuakes() returned an error: %v", err)
+	}
+
+	// A revision overwrites the stored copy, a stale one does not
+	revised := quake
+	revised.Mag, revised.Updated = 5.4, origin.Add(2*time.Hour)
+	if err := saveQuakes(path, []QuakeRecord{revised}); err != nil {
+		t.Fatalf("saveQuakes() returned an error: %v", err)
+	}
+	stale := quake
+	stale.Mag = 4.9
+	if err := saveQuakes(path, []QuakeRecord{stale}); err != nil {
+		t.Fatalf("saveQuakes() returned an error: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM earthquakes").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 stored earthquakes, got %d", count)
+	}
+
+	var mag, depth, lon, lat float64
+	var place string
+	var eventTime, updated int64
+	row := db.QueryRow("SELECT place, mag, depth, time, updated, lon, lat FROM earthquakes WHERE id = 'a'")
+	if err := row.Scan(&place, &mag, &depth, &eventTime, &updated, &lon, &lat); err != nil {
+		t.Fatal(err)
+	}
+	if place != "First" || mag != 5.4 || depth != 10 || lon != 142.1 || lat != 38.3 {
+		t.Errorf("Unexpected row: %s %v %v %v %v", place, mag, depth, lon, lat)
+	}
+	if eventTime != origin.UnixMilli() || updated != origin.Add(2*time.Hour).UnixMilli() {
+		t.Errorf("Unexpected times %d and %d", eventTime, updated)
+	}
+
+	var nullMag sql.NullFloat64
+	if err := db.QueryRow("SELECT mag FROM earthquakes WHERE id = 'b'").Scan(&nullMag); err != nil {
+		t.Fatal(err)
+	}
+	if nullMag.Valid {
+		t.Errorf("Expected a NULL magnitude, got %v", nullMag.Float64)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+// dedupFeatures drops repeated events, as happens when overlapping feeds are
+// merged. Features sharing an ID are kept once, at the position of the first,
+// using the copy with the latest Updated time since USGS revises events after
+// publishing them. Features without an ID are all kept.
+func dedupFeatures(features []Feature) []Feature {
+	index := make(map[string]int, len(features))
+	deduped := make([]Feature, 0, len(features))
+
+	for _, feature := range features {
+		if feature.ID == "" {
+			deduped = append(deduped, feature)
+			continue
+		}
+
+		i, ok := index[feature.ID]
+		if !ok {
+			index[feature.ID] = len(deduped)
+			deduped = append(deduped, feature)
+			continue
+		}
+		if feature.Properties.Updated > deduped[i].Properties.Updated {
+			deduped[i] = feature
+		}
+	}
+
+	return deduped
+}
+
+// dropRepeats keeps the first feature of each ID. Within a single feed a
+// repeated ID is a stray second listing rather than a revision, and keeping
+// the first matches streamQuakes, which cannot look ahead for a later copy.
+// Revisions are weighed when feeds are merged, by dedupFeatures.
+func dropRepeats(features []Feature) []Feature {
+	seen := make(map[string]bool, len(features))
+	kept := make([]Feature, 0, len(features))
+
+	for _, feature := range features {
+		if feature.ID != "" {
+			if seen[feature.ID] {
+				continue
+			}
+			seen[feature.ID] = true
+		}
+		kept = append(kept, feature)
+	}
+
+	return kept
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestDedupFeatures(t *testing.T) {
+	feature := func(id string, mag float64, updated int64) Feature {
+		return Feature{ID: id, Properties: Properties{Mag: &mag, Updated: updated}}
+	}
+	features := []Feature{
+		feature("us1", 5.1, 100),
+		feature("us2", 6.0, 100),
+		feature("us1", 5.3, 200),
+		feature("us2", 5.8, 50),
+		feature("", 4.0, 0),
+		feature("", 4.1, 0),
+	}
+
+	deduped := dedupFeatures(features)
+
+	if len(deduped) != 4 {
+		t.Fatalf("Expected 4 features, got %d: %+v", len(deduped), deduped)
+	}
+	if deduped[0].ID != "us1" || *deduped[0].Properties.Mag != 5.3 {
+		t.Errorf("Expected the latest revision of us1 first, got %+v", deduped[0])
+	}
+	if deduped[1].ID != "us2" || *deduped[1].Properties.Mag != 6.0 {
+		t.Errorf("Expected the original us2 to be kept over an older revision, got %+v", deduped[1])
+	}
+	if *deduped[2].Properties.Mag != 4.0 || *deduped[3].Properties.Mag != 4.1 {
+		t.Errorf("Expected features without an ID to be kept, got %+v", deduped[2:])
+	}
+}
+
+func TestDropRepeats(t *testing.T) {
+	feature := func(id string, mag float64, updated int64) Feature {
+		return Feature{ID: id, Properties: Properties{Mag: &mag, Updated: updated}}
+	}
+	features := []Feature{
+		feature("us1", 5.1, 100),
+		feature("us2", 6.0, 100),
+		feature("us1", 5.3, 200),
+		feature("", 4.0, 0),
+		feature("", 4.1, 0),
+	}
+
+	kept := dropRepeats(features)
+
+	if len(kept) != 4 {
+		t.Fatalf("Expected 4 features, got %d: %+v", len(kept), kept)
+	}
+	if kept[0].ID != "us1" || *kept[0].Properties.Mag != 5.1 {
+		t.Errorf("Expected the first listing of us1 to be kept, got %+v", kept[0])
+	}
+	if *kept[2].Properties.Mag != 4.0 || *kept[3].Properties.Mag != 4.1 {
+		t.Errorf("Expected features without an ID to be kept, got %+v", kept[2:])
+	}
+}
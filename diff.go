@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotQuake is an earthquake as recorded in a -diff snapshot. Mag is nil
+// when the magnitude was unknown.
+type snapshotQuake struct {
+	ID      string    `json:"id"`
+	Place   string    `json:"place"`
+	Mag     *float64  `json:"mag"`
+	Time    time.Time `json:"time"`
+	Updated time.Time `json:"updated"`
+}
+
+// magnitudeRevision is an earthquake whose magnitude changed since the
+// snapshot; previous is nil when it used to be unknown.
+type magnitudeRevision struct {
+	quake    QuakeRecord
+	previous *float64
+}
+
+// quakeDiff is what changed between a snapshot and the current matches.
+type quakeDiff struct {
+	added   []QuakeRecord
+	revised []magnitudeRevision
+	dropped []snapshotQuake
+}
+
+// changes counts every difference.
+func (d quakeDiff) changes() int {
+	return len(d.added) + len(d.revised) + len(d.dropped)
+}
+
+// newSnapshot records quakes for the next -diff run. Earthquakes without an
+// ID cannot be matched up later and are left out.
+func newSnapshot(quakes []QuakeRecord) map[string]snapshotQuake {
+	snapshot := make(map[string]snapshotQuake, len(quakes))
+	for _, quake := range quakes {
+		if quake.ID == "" {
+			continue
+		}
+		entry := snapshotQuake{ID: quake.ID, Place: quake.Place, Time: quake.Time, Updated: quake.Updated}
+		if !quake.unknownMag {
+			mag := quake.Mag
+			entry.Mag = &mag
+		}
+		snapshot[quake.ID] = entry
+	}
+	return snapshot
+}
+
+// diffQuakes compares the current matches against the previous snapshot:
+// earthquakes it lacks are new, ones USGS revised to a different magnitude
+// are revisions, and ones no longer matched, usually because they aged out
+// of the feed window, are dropped (oldest first).
+func diffQuakes(previous map[string]snapshotQuake, quakes []QuakeRecord) quakeDiff {
+	var diff quakeDiff
+	current := make(map[string]bool, len(quakes))
+
+	for _, quake := range quakes {
+		if quake.ID == "" {
+			continue
+		}
+		current[quake.ID] = true
+
+		old, ok := previous[quake.ID]
+		if !ok {
+			diff.added = append(diff.added, quake)
+			continue
+		}
+		magnitudeChanged := (old.Mag == nil) != quake.unknownMag || (old.Mag != nil && *old.Mag != quake.Mag)
+		if quake.Updated.After(old.Updated) && magnitudeChanged {
+			diff.revised = append(diff.revised, magnitudeRevision{quake: quake, previous: old.Mag})
+		}
+	}
+
+	for id, old := range previous {
+		if !current[id] {
+			diff.dropped = append(diff.dropped, old)
+		}
+	}
+	sort.Slice(diff.dropped, func(i, j int) bool { return diff.dropped[i].Time.Before(diff.dropped[j].Time) })
+
+	return diff
+}
+
+// snapshotPath returns the snapshot file under the cache directory for the
+// data source in opts, so each feed or input file has its own.
+func snapshotPath(opts options) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	source := opts.url
+	if len(opts.feedURLs) > 1 {
+		source = strings.Join(opts.feedURLs, ",")
+	}
+	if opts.inputPath != "" {
+		abs, err := filepath.Abs(opts.inputPath)
+		if err != nil {
+			return "", err
+		}
+		source = "file:" + abs
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".snapshot.json"), nil
+}
+
+// loadSnapshot reads a snapshot; loaded reports whether there was one. A
+// missing one is empty, as on the first run.
+func loadSnapshot(path string) (snapshot map[string]snapshotQuake, loaded bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]snapshotQuake{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, false, err
+	}
+	return snapshot, true, nil
+}
+
+// saveSnapshot writes a snapshot, creating the cache directory as needed.
+func saveSnapshot(path string, snapshot map[string]snapshotQuake) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// reportDiff prints what changed in the matching earthquakes since the
+// previous -diff run and saves the current ones for the next. It returns the
+// number of changes. The first run only sets the baseline, so it raises no
+// alerts for what is already in the feed, as watchQuakes does.
+func reportDiff(ctx context.Context, w io.Writer, opts options) (int, error) {
+	earthquakeData, _, err := loadEarthquakeData(ctx, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch earthquake data: %w", err)
+	}
+	quakes := collectQuakes(earthquakeData, opts)
+
+	path, err := snapshotPath(opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate snapshot: %w", err)
+	}
+	previous, loaded, err := loadSnapshot(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	diff := diffQuakes(previous, quakes)
+	printDiff(w, diff, opts)
+	if loaded {
+		alertQuakes(ctx, diff.added, opts)
+	}
+
+	if err := saveSnapshot(path, newSnapshot(quakes)); err != nil {
+		return 0, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return diff.changes(), nil
+}
+
+// printDiff prints the new, revised and dropped sections of a diff.
+func printDiff(w io.Writer, diff quakeDiff, opts options) {
+	fmt.Fprintf(w, "New: %s\n", plural(len(diff.added), "earthquake"))
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	for _, quake := range diff.added {
+		printEarthquakeInfo(w, quake, opts)
+	}
+
+	fmt.Fprintf(w, "Revised: %s\n", plural(len(diff.revised), "magnitude"))
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	for _, revision := range diff.revised {
+		quake := revision.quake
+		fmt.Fprintf(w, "%s: %s -> %s (updated %s)\n", quake.Place, describeMagnitude(revision.previous), describeMagnitude(quakeMagnitude(quake)),
+			quake.Updated.In(opts.location).Format(dateFormat))
+	}
+	if len(diff.revised) > 0 {
+		fmt.Fprintln(w, "-------------------------------------------------------------------")
+	}
+
+	fmt.Fprintf(w, "Dropped: %s\n", plural(len(diff.dropped), "earthquake"))
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	for _, old := range diff.dropped {
+		fmt.Fprintf(w, "%s %s at %s\n", describeMagnitude(old.Mag), old.Place, old.Time.In(opts.location).Format(dateFormat))
+	}
+}
+
+// quakeMagnitude returns the magnitude of quake, or nil when unknown.
+func quakeMagnitude(quake QuakeRecord) *float64 {
+	if quake.unknownMag {
+		return nil
+	}
+	return &quake.Mag
+}
+
+// describeMagnitude renders a magnitude as "M4.5", or "M?" when unknown.
+func describeMagnitude(mag *float64) string {
+	if mag == nil {
+		return "M?"
+	}
+	return fmt.Sprintf("M%.1f", *mag)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffQuakes(t *testing.T) {
+	base := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	mag := func(m float64) *float64 { return &m }
+	previous := map[string]snapshotQuake{
+		"kept":    {ID: "kept", Mag: mag(4.5), Time: base, Updated: base},
+		"revised": {ID: "revised", Mag: mag(5.0), Time: base, Updated: base},
+		"touched": {ID: "touched", Mag: mag(3.0), Time: base, Updated: base},
+		"late":    {ID: "late", Time: base.Add(time.Hour), Updated: base},
+		"early":   {ID: "early", Time: base.Add(-time.Hour), Updated: base},
+	}
+	quakes := []QuakeRecord{
+		{ID: "kept", Mag: 4.5, Updated: base},
+		{ID: "revised", Mag: 5.3, Updated: base.Add(time.Hour)},
+		// Updated for another reason, with the same magnitude
+		{ID: "touched", Mag: 3.0, Updated: base.Add(time.Hour)},
+		{ID: "new", Mag: 2.1},
+		{Mag: 6.0},
+	}
+
+	diff := diffQuakes(previous, quakes)
+	if len(diff.added) != 1 || diff.added[0].ID != "new" {
+		t.Errorf("Expected only new to be added, got %+v", diff.added)
+	}
+	if len(diff.revised) != 1 || diff.revised[0].quake.ID != "revised" || *diff.revised[0].previous != 5.0 {
+		t.Errorf("Expected revised to go from 5.0, got %+v", diff.revised)
+	}
+	if len(diff.dropped) != 2 || diff.dropped[0].ID != "early" || diff.dropped[1].ID != "late" {
+		t.Errorf("Expected early and late to be dropped in time order, got %+v", diff.dropped)
+	}
+	if diff.changes() != 4 {
+		t.Errorf("Expected 4 changes, got %d", diff.changes())
+	}
+
+	if diff := diffQuakes(newSnapshot(quakes), quakes); diff.changes() != 0 {
+		t.Errorf("Expected no changes against its own snapshot, got %+v", diff)
+	}
+}
+
+func TestReportDiff(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	feedPath := filepath.Join(dir, "feed.geojson")
+	writeFeed := func(features string) {
+		t.Helper()
+		data := `{"type": "FeatureCollection", "features": [` + features + `]}`
+		if err := os.WriteFile(feedPath, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts, err := parseFlags([]string{"-file", feedPath, "-diff", "-tz", "UTC"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	writeFeed(`{"id": "a", "properties": {"mag": 4.1, "place": "First", "time": 1704153600000, "updated": 1704153600000}},
+		{"id": "b", "properties": {"mag": 5.2, "place": "Second", "time": 1704157200000, "updated": 1704157200000}}`)
+	var buf bytes.Buffer
+	if count, err := reportDiff(context.Background(), &buf, opts); err != nil || count != 2 {
+		t.Fatalf("Expected 2 changes on the first run, got %d (%v)", count, err)
+	}
+	if !strings.Contains(buf.String(), "New: 2 earthquakes") {
+		t.Errorf("Expected both earthquakes to be new, got:\n%s", buf.String())
+	}
+
+	writeFeed(`{"id": "b", "properties": {"mag": 5.4, "place": "Second", "time": 1704157200000, "updated": 1704160800000}},
+		{"id": "c", "properties": {"mag": 3.0, "place": "Third", "time": 1704160800000, "updated": 1704160800000}}`)
+	buf.Reset()
+	if count, err := reportDiff(context.Background(), &buf, opts); err != nil || count != 3 {
+		t.Fatalf("Expected 3 changes on the second run, got %d (%v)", count, err)
+	}
+	output := buf.String()
+	for _, want := range []string{"New: 1 earthquake\n", "Third", "Revised: 1 magnitude", "Second: M5.2 -> M5.4", "Dropped: 1 earthquake", "M4.1 First at 2024-01-02"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	if count, err := reportDiff(context.Background(), &buf, opts); err != nil || count != 0 {
+		t.Errorf("Expected no changes on an unchanged feed, got %d (%v)", count, err)
+	}
+}
+
+func TestReportDiffAlertsAfterBaseline(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	var delivered []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		delivered = append(delivered, payload.ID)
+	}))
+	defer hook.Close()
+
+	feedPath := filepath.Join(dir, "feed.geojson")
+	writeFeed := func(features string) {
+		t.Helper()
+		data := `{"type": "FeatureCollection", "features": [` + features + `]}`
+		if err := os.WriteFile(feedPath, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts, err := parseFlags([]string{"-file", feedPath, "-diff", "-webhook", hook.URL})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	// The first run only records the baseline
+	writeFeed(`{"id": "a", "properties": {"mag": 4.1, "place": "First", "time": 1704153600000}}`)
+	var buf bytes.Buffer
+	if _, err := reportDiff(context.Background(), &buf, opts); err != nil {
+		t.Fatalf("reportDiff() returned an error: %v", err)
+	}
+	if len(delivered) != 0 {
+		t.Errorf("Expected no alerts on the first run, got %v", delivered)
+	}
+
+	writeFeed(`{"id": "a", "properties": {"mag": 4.1, "place": "First", "time": 1704153600000}},
+		{"id": "b", "properties": {"mag": 5.2, "place": "Second", "time": 1704157200000}}`)
+	if _, err := reportDiff(context.Background(), &buf, opts); err != nil {
+		t.Fatalf("reportDiff() returned an error: %v", err)
+	}
+	if len(delivered) != 1 || delivered[0] != "b" {
+		t.Errorf("Expected an alert for b only, got %v", delivered)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// siPrefixes are the SI prefixes for successive powers of 1000, starting
+// at 10^0.
+var siPrefixes = []string{"", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// energyJoules estimates the seismic energy radiated by an earthquake of
+// moment magnitude mag, using the Gutenberg-Richter relation
+// log10(E) = 4.8 + 1.5*Mw. Each whole magnitude is about 32 times the energy.
+func energyJoules(mag float64) float64 {
+	return math.Pow(10, 4.8+1.5*mag)
+}
+
+// formatSI renders value with one decimal and the largest SI prefix that
+// keeps it at or above 1, e.g. "2.0 PJ" for 2e15 joules.
+func formatSI(value float64, unit string) string {
+	prefix := 0
+	for math.Abs(value) >= 1000 && prefix < len(siPrefixes)-1 {
+		value /= 1000
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %s%s", value, siPrefixes[prefix], unit)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEnergyJoules(t *testing.T) {
+	tests := []struct {
+		mag      float64
+		expected float64
+	}{
+		{0, math.Pow(10, 4.8)},
+		{4, math.Pow(10, 10.8)},
+		{7, math.Pow(10, 15.3)},
+	}
+
+	for _, test := range tests {
+		if got := energyJoules(test.mag); math.Abs(got-test.expected)/test.expected > 1e-9 {
+			t.Errorf("energyJoules(%v): expected %g, got %g", test.mag, test.expected, got)
+		}
+	}
+
+	// One M7 releases far more than ten M4s
+	if ten := 10 * energyJoules(4); energyJoules(7) < 1000*ten {
+		t.Errorf("Expected an M7 to dwarf ten M4s, got %g vs %g", energyJoules(7), ten)
+	}
+}
+
+func TestFormatSI(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{0, "0.0 J"},
+		{999, "999.0 J"},
+		{1500, "1.5 kJ"},
+		{energyJoules(7), "2.0 PJ"},
+		{energyJoules(9), "2.0 EJ"},
+		{2e30, "2000000.0 YJ"},
+	}
+
+	for _, test := range tests {
+		if got := formatSI(test.value, "J"); got != test.expected {
+			t.Errorf("formatSI(%g): expected %q, got %q", test.value, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A -filter expression combines comparisons of earthquake fields with
+// literals, e.g. mag >= 5 && depth < 30 && place contains "Alaska":
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = field [ op literal ]
+//	op         = "==" | "!=" | "<" | "<=" | ">" | ">=" | "contains"
+//
+// A field alone is only allowed for the true/false fields, like tsunami.
+// Field names, contains, true and false ignore case, as do text comparisons.
+// The time fields compare with a string in the forms -since takes: an
+// RFC3339 time or a duration ago, e.g. time > "6h". A comparison with a
+// value the feed did not give, such as a null magnitude, is false.
+
+// filter is a parsed -filter expression.
+type filter func(QuakeRecord) bool
+
+// fieldKind is the type of a filter field or literal.
+type fieldKind int
+
+const (
+	numberKind fieldKind = iota
+	textKind
+	boolKind
+	timeKind
+)
+
+func (k fieldKind) String() string {
+	return [...]string{"number", "text", "boolean", "time"}[k]
+}
+
+// filterValue is a field value or literal of its kind.
+type filterValue struct {
+	kind   fieldKind
+	number float64
+	text   string
+	flag   bool
+	moment time.Time
+}
+
+// filterField reads a field of an earthquake; ok is false when the feed did
+// not give it.
+type filterField struct {
+	kind  fieldKind
+	value func(QuakeRecord) (v filterValue, ok bool)
+}
+
+func numberField(value func(QuakeRecord) (float64, bool)) filterField {
+	return filterField{numberKind, func(q QuakeRecord) (filterValue, bool) {
+		n, ok := value(q)
+		return filterValue{kind: numberKind, number: n}, ok
+	}}
+}
+
+func textField(value func(QuakeRecord) string) filterField {
+	return filterField{textKind, func(q QuakeRecord) (filterValue, bool) {
+		return filterValue{kind: textKind, text: value(q)}, true
+	}}
+}
+
+func timeField(value func(QuakeRecord) time.Time) filterField {
+	return filterField{timeKind, func(q QuakeRecord) (filterValue, bool) {
+		return filterValue{kind: timeKind, moment: value(q)}, true
+	}}
+}
+
+// filterFields are the fields a -filter can compare, named as in -columns.
+var filterFields = map[string]filterField{
+	"id":        textField(func(q QuakeRecord) string { return q.ID }),
+	"place":     textField(func(q QuakeRecord) string { return q.Place }),
+	"mag":       numberField(func(q QuakeRecord) (float64, bool) { return q.Mag, !q.unknownMag }),
+	"magtype":   textField(func(q QuakeRecord) string { return q.MagType }),
+	"time":      timeField(func(q QuakeRecord) time.Time { return q.Time }),
+	"updated":   timeField(func(q QuakeRecord) time.Time { return q.Updated }),
+	"longitude": numberField(func(q QuakeRecord) (float64, bool) { return q.Longitude, q.hasLocation }),
+	"latitude":  numberField(func(q QuakeRecord) (float64, bool) { return q.Latitude, q.hasLocation }),
+	"depth":     numberField(func(q QuakeRecord) (float64, bool) { return q.Depth, q.hasDepth }),
+	"distance": numberField(func(q QuakeRecord) (float64, bool) {
+		if q.Distance == nil {
+			return 0, false
+		}
+		return *q.Distance, true
+	}),
+	"sig": numberField(func(q QuakeRecord) (float64, bool) { return float64(q.Sig), true }),
+	"felt": numberField(func(q QuakeRecord) (float64, bool) {
+		if q.Felt == nil {
+			return 0, false
+		}
+		return float64(*q.Felt), true
+	}),
+	"tsunami": {boolKind, func(q QuakeRecord) (filterValue, bool) {
+		return filterValue{kind: boolKind, flag: q.Tsunami}, true
+	}},
+	"alert":  textField(func(q QuakeRecord) string { return q.Alert }),
+	"status": textField(func(q QuakeRecord) string { return q.Status }),
+	"net":    textField(func(q QuakeRecord) string { return q.Net }),
+	"url":    textField(func(q QuakeRecord) string { return q.URL }),
+}
+
+// filterFieldNames lists the filterFields in order.
+func filterFieldNames() []string {
+	names := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tokenKind classifies the tokens of a -filter expression.
+type tokenKind int
+
+const (
+	endToken tokenKind = iota
+	identToken
+	numberToken
+	stringToken
+	opToken
+)
+
+// token is a lexeme of a -filter expression and the byte offset it starts at.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case endToken:
+		return "end of expression"
+	case stringToken:
+		return strconv.Quote(t.text)
+	}
+	return t.text
+}
+
+// operators are the symbols of the expression language, longest first so
+// "<=" is not read as "<".
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"}
+
+// tokenize splits src into tokens, ending with an endToken.
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"':
+			// Find the closing quote, skipping escaped ones
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			text, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d", i)
+			}
+			tokens = append(tokens, token{stringToken, text, i})
+			i = end + 1
+		case isDigit(c) || c == '.' || (c == '-' && i+1 < len(src) && (isDigit(src[i+1]) || src[i+1] == '.')):
+			end := i + 1
+			for end < len(src) && (isDigit(src[end]) || src[end] == '.') {
+				end++
+			}
+			if _, err := strconv.ParseFloat(src[i:end], 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", src[i:end], i)
+			}
+			tokens = append(tokens, token{numberToken, src[i:end], i})
+			i = end
+		case isLetter(c):
+			end := i + 1
+			for end < len(src) && (isLetter(src[end]) || isDigit(src[end])) {
+				end++
+			}
+			tokens = append(tokens, token{identToken, src[i:end], i})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				if c == '=' {
+					return nil, fmt.Errorf(`unexpected "=" at offset %d (use == to compare)`, i)
+				}
+				return nil, fmt.Errorf("unexpected %q at offset %d", src[i:i+1], i)
+			}
+			tokens = append(tokens, token{opToken, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{endToken, "", len(src)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+// filterParser is a recursive descent parser over the tokens of a -filter
+// expression.
+type filterParser struct {
+	tokens []token
+	next   int
+	// now is the time durations in time comparisons count back from
+	now time.Time
+}
+
+// parseFilter parses a -filter expression into a filter; a duration compared
+// with a time field counts back from now.
+func parseFilter(src string, now time.Time) (filter, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens, now: now}
+	if p.peek().kind == endToken {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != endToken {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+	}
+	return f, nil
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.next]
+}
+
+// accept consumes the next token if it is the operator or keyword text.
+func (p *filterParser) accept(text string) bool {
+	if t := p.peek(); (t.kind == opToken || t.kind == identToken) && strings.EqualFold(t.text, text) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(q QuakeRecord) bool { return l(q) || right(q) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(q QuakeRecord) bool { return l(q) && right(q) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filter, error) {
+	if p.accept("!") {
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(q QuakeRecord) bool { return !f(q) }, nil
+	}
+
+	if open := p.peek(); p.accept("(") {
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ) for the ( at offset %d", open.pos)
+		}
+		return f, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filter, error) {
+	name := p.peek()
+	if name.kind != identToken {
+		return nil, fmt.Errorf("expected a field at offset %d, got %s", name.pos, name)
+	}
+	field, ok := filterFields[strings.ToLower(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at offset %d (valid fields: %s)", name.text, name.pos, strings.Join(filterFieldNames(), ", "))
+	}
+	p.next++
+
+	op := p.peek()
+	if op.kind == identToken {
+		op.text = strings.ToLower(op.text)
+	}
+	if (op.kind != opToken && op.kind != identToken) || !comparisonOperators[op.text] {
+		if field.kind != boolKind {
+			return nil, fmt.Errorf("expected a comparison after %s at offset %d, got %s", name.text, op.pos, op)
+		}
+		// A true/false field on its own holds when it is true
+		return func(q QuakeRecord) bool {
+			v, ok := field.value(q)
+			return ok && v.flag
+		}, nil
+	}
+	p.next++
+
+	literal, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	if field.kind == timeKind && literal.kind == textKind {
+		t := p.tokens[p.next-1]
+		if literal.moment, err = parseTimeBound(literal.text, p.now); err != nil {
+			return nil, fmt.Errorf("%v at offset %d", err, t.pos)
+		}
+		literal.kind = timeKind
+	}
+	if literal.kind != field.kind {
+		t := p.tokens[p.next-1]
+		return nil, fmt.Errorf("cannot compare %s, a %s field, with %s at offset %d", name.text, field.kind, t, t.pos)
+	}
+
+	compare, err := comparison(op.text, field.kind)
+	if err != nil {
+		return nil, fmt.Errorf("%v at offset %d", err, op.pos)
+	}
+	return func(q QuakeRecord) bool {
+		v, ok := field.value(q)
+		return ok && compare(v, literal)
+	}, nil
+}
+
+// parseLiteral reads the number, string, true or false compared with.
+func (p *filterParser) parseLiteral() (filterValue, error) {
+	t := p.peek()
+	p.next++
+	switch {
+	case t.kind == numberToken:
+		n, _ := strconv.ParseFloat(t.text, 64)
+		return filterValue{kind: numberKind, number: n}, nil
+	case t.kind == stringToken:
+		return filterValue{kind: textKind, text: t.text}, nil
+	case t.kind == identToken && (strings.EqualFold(t.text, "true") || strings.EqualFold(t.text, "false")):
+		return filterValue{kind: boolKind, flag: strings.EqualFold(t.text, "true")}, nil
+	}
+	return filterValue{}, fmt.Errorf("expected a number, string, true or false at offset %d, got %s", t.pos, t)
+}
+
+// comparisonOperators are the operators between a field and a literal.
+var comparisonOperators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "contains": true,
+}
+
+// comparison returns the test for op between values of kind.
+func comparison(op string, kind fieldKind) (func(v, literal filterValue) bool, error) {
+	switch kind {
+	case numberKind, timeKind:
+		compare := map[string]func(a, b float64) bool{
+			"==": func(a, b float64) bool { return a == b },
+			"!=": func(a, b float64) bool { return a != b },
+			"<":  func(a, b float64) bool { return a < b },
+			"<=": func(a, b float64) bool { return a <= b },
+			">":  func(a, b float64) bool { return a > b },
+			">=": func(a, b float64) bool { return a >= b },
+		}[op]
+		if compare == nil {
+			break
+		}
+		if kind == timeKind {
+			// Compare the ordering of the two times with zero
+			return func(v, literal filterValue) bool { return compare(float64(v.moment.Compare(literal.moment)), 0) }, nil
+		}
+		return func(v, literal filterValue) bool { return compare(v.number, literal.number) }, nil
+	case textKind:
+		switch op {
+		case "==":
+			return func(v, literal filterValue) bool { return strings.EqualFold(v.text, literal.text) }, nil
+		case "!=":
+			return func(v, literal filterValue) bool { return !strings.EqualFold(v.text, literal.text) }, nil
+		case "contains":
+			return func(v, literal filterValue) bool {
+				return strings.Contains(strings.ToLower(v.text), strings.ToLower(literal.text))
+			}, nil
+		}
+	case boolKind:
+		switch op {
+		case "==":
+			return func(v, literal filterValue) bool { return v.flag == literal.flag }, nil
+		case "!=":
+			return func(v, literal filterValue) bool { return v.flag != literal.flag }, nil
+		}
+	}
+	return nil, fmt.Errorf("%s cannot compare %s fields", op, kind)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`mag>=-1.5&&(place contains "say \"hi\"")||!tsunami`)
+	if err != nil {
+		t.Fatalf("tokenize() returned an error: %v", err)
+	}
+
+	var got []string
+	for _, tok := range tokens {
+		got = append(got, tok.String())
+	}
+	expected := `mag >= -1.5 && ( place contains "say \"hi\"" ) || ! tsunami end of expression`
+	if strings.Join(got, " ") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, " "))
+	}
+	if tokens[2].pos != 5 || tokens[7].kind != stringToken || tokens[7].text != `say "hi"` {
+		t.Errorf("Expected offsets and unquoted strings, got %+v", tokens)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	felt, distance := 12, 80.0
+	alaska := QuakeRecord{Place: "10 km W of Anchorage, Alaska", Mag: 5.4, Depth: 25, hasDepth: true,
+		Longitude: -150, Latitude: 61.2, hasLocation: true, Sig: 449, Felt: &felt, Distance: &distance,
+		Tsunami: true, Alert: "green", Status: "reviewed", Net: "ak", MagType: "ml", ID: "ak1", URL: "https://example.com/ak1"}
+	unknown := QuakeRecord{Place: "Somewhere in Alaska", unknownMag: true}
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	alaska.Time, alaska.Updated = now.Add(-2*time.Hour), now.Add(-time.Hour)
+	unknown.Time, unknown.Updated = now.Add(-48*time.Hour), now.Add(-48*time.Hour)
+
+	tests := []struct {
+		expr    string
+		alaska  bool
+		unknown bool
+	}{
+		{`mag >= 5 && depth < 30 && place contains "Alaska"`, true, false},
+		{`mag >= 5.5`, false, false},
+		{`mag == 5.4 && mag != 5`, true, false},
+		{`mag > 5 && mag <= 5.4`, true, false},
+		{`place contains "alaska"`, true, true},
+		{`place == "10 KM W OF ANCHORAGE, ALASKA"`, true, false},
+		{`net != "us"`, true, true},
+		{`status == "reviewed" || status == "automatic"`, true, false},
+		{`mag < 3 || place contains "somewhere"`, false, true},
+		{`!(mag >= 5)`, false, true},
+		{`!mag >= 5`, false, true},
+		{`mag > 6 || mag > 5 && depth > 30`, false, false},
+		{`(mag > 6 || mag > 5) && depth < 30`, true, false},
+		{`longitude < -149 && latitude > 61`, true, false},
+		{`felt >= 10 && distance < 100 && sig > 400`, true, false},
+		{`felt < 100 || distance > 0`, true, false},
+		{`tsunami`, true, false},
+		{`!tsunami`, false, true},
+		{`tsunami == false`, false, true},
+		{`tsunami && alert == "green"`, true, false},
+		{`MAG > 5 && magtype == "ml" && id == "ak1" && url contains "ak1"`, true, false},
+		{`Place CONTAINS "alaska" && Tsunami == TRUE`, true, false},
+		{`tsunami == False`, false, true},
+		{`alert == ""`, false, true},
+		{`depth >= 0`, true, false},
+		{`time >= "6h"`, true, false},
+		{`time < "2024-01-02T00:00:00Z"`, false, true},
+		{`time == "2024-01-02T10:00:00Z"`, true, false},
+		{`updated >= "1h" || mag < 3`, true, false},
+		{`updated != "30m"`, true, true},
+	}
+
+	for _, tt := range tests {
+		f, err := parseFilter(tt.expr, now)
+		if err != nil {
+			t.Errorf("parseFilter(%q) returned an error: %v", tt.expr, err)
+			continue
+		}
+		if got := f(alaska); got != tt.alaska {
+			t.Errorf("%s: expected %v for the Alaska earthquake, got %v", tt.expr, tt.alaska, got)
+		}
+		if got := f(unknown); got != tt.unknown {
+			t.Errorf("%s: expected %v for the earthquake without a magnitude, got %v", tt.expr, tt.unknown, got)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{``, "empty expression"},
+		{`   `, "empty expression"},
+		{`magnitude > 5`, `unknown field "magnitude" at offset 0 (valid fields: alert, depth,`},
+		{`mag = 5`, `unexpected "=" at offset 4 (use == to compare)`},
+		{`mag > 5 &`, `unexpected "&" at offset 8`},
+		{`mag >`, "expected a number, string, true or false at offset 5, got end of expression"},
+		{`mag > depth`, "expected a number, string, true or false at offset 6, got depth"},
+		{`mag 5`, "expected a comparison after mag at offset 4, got 5"},
+		{`mag`, "expected a comparison after mag at offset 3, got end of expression"},
+		{`mag > "5"`, `cannot compare mag, a number field, with "5" at offset 6`},
+		{`place == 5`, "cannot compare place, a text field, with 5 at offset 9"},
+		{`tsunami == "yes"`, `cannot compare tsunami, a boolean field, with "yes"`},
+		{`place > "A"`, "> cannot compare text fields at offset 6"},
+		{`mag contains 5`, "contains cannot compare number fields at offset 4"},
+		{`tsunami < true`, "< cannot compare boolean fields"},
+		{`(mag > 5`, "missing ) for the ( at offset 0"},
+		{`mag > 5)`, "unexpected ) at offset 7"},
+		{`mag > 5 place == "x"`, "unexpected place at offset 8"},
+		{`&& mag > 5`, "expected a field at offset 0, got &&"},
+		{`place contains "Alaska`, "unterminated string at offset 15"},
+		{`place contains "\q"`, "invalid string at offset 15"},
+		{`mag > 1.2.3`, `invalid number "1.2.3" at offset 6`},
+		{`mag > 5 # comment`, `unexpected "#" at offset 8`},
+		{`time > "yesterday"`, `"yesterday" is neither an RFC3339 time nor a duration like 24h at offset 7`},
+		{`time > 5`, "cannot compare time, a time field, with 5 at offset 7"},
+		{`updated contains "1h"`, "contains cannot compare time fields at offset 8"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseFilter(tt.expr, time.Now())
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("parseFilter(%q): expected an error containing %q, got %v", tt.expr, tt.expected, err)
+		}
+	}
+}
+
+func TestParseFlagsFilter(t *testing.T) {
+	opts, err := parseFlags([]string{"-filter", `mag >= 5 && place contains "Alaska"`, "-min", "5.2"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	tests := []struct {
+		quake    QuakeRecord
+		expected bool
+	}{
+		{QuakeRecord{Place: "Alaska", Mag: 5.4}, true},
+		{QuakeRecord{Place: "Alaska", Mag: 5.1}, false},
+		{QuakeRecord{Place: "Japan", Mag: 6}, false},
+	}
+	for _, tt := range tests {
+		if got := opts.matches(tt.quake); got != tt.expected {
+			t.Errorf("Expected matches(%s M%v) to be %v alongside -min, got %v", tt.quake.Place, tt.quake.Mag, tt.expected, got)
+		}
+	}
+
+	if _, err := parseFlags([]string{"-filter", "mag >"}); err == nil || !strings.HasPrefix(err.Error(), "invalid -filter: ") {
+		t.Errorf("Expected an invalid -filter error, got %v", err)
+	}
+}
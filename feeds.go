@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// feedBaseURL is the USGS summary feed directory.
+const feedBaseURL = "https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/"
+
+// defaultFeed is the feed queried when -feed is not given.
+const defaultFeed = "significant_month"
+
+// Magnitude classes and periods published by USGS; every combination is a feed.
+var (
+	feedClasses = []string{"significant", "4.5", "2.5", "1.0", "all"}
+	feedPeriods = []string{"hour", "day", "week", "month"}
+)
+
+// feedNames lists every known feed, e.g. "4.5_week".
+func feedNames() []string {
+	var names []string
+	for _, class := range feedClasses {
+		for _, period := range feedPeriods {
+			names = append(names, class+"_"+period)
+		}
+	}
+	return names
+}
+
+// feedDays maps the -days choices to the feed period covering them.
+var feedDays = map[int]string{
+	1:  "day",
+	7:  "week",
+	30: "month",
+}
+
+// feedDayChoices lists the accepted -days values in increasing order.
+func feedDayChoices() []string {
+	var choices []string
+	for _, period := range feedPeriods {
+		for days, p := range feedDays {
+			if p == period {
+				choices = append(choices, strconv.Itoa(days))
+			}
+		}
+	}
+	return choices
+}
+
+// feedForDays returns the feed name for -days and -class, e.g. "4.5_week"
+// for 7 and "4.5". A zero days selects a month and an empty class
+// "significant", as in defaultFeed. Unavailable combinations are reported
+// with the matrix of ones USGS publishes.
+func feedForDays(days int, class string) (string, error) {
+	if days == 0 {
+		days = 30
+	}
+	if class == "" {
+		class = "significant"
+	}
+
+	name := class + "_" + feedDays[days]
+	if _, ok := feedDays[days]; ok {
+		for _, feed := range feedNames() {
+			if feed == name {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no USGS feed for -days %d -class %s (available: -days %s with -class %s)",
+		days, class, strings.Join(feedDayChoices(), ", "), strings.Join(feedClasses, ", "))
+}
+
+// feedURL returns the GeoJSON URL for a feed name, or an error listing the
+// valid names when the feed is unknown.
+func feedURL(feed string) (string, error) {
+	for _, name := range feedNames() {
+		if name == feed {
+			return feedBaseURL + feed + ".geojson", nil
+		}
+	}
+	return "", fmt.Errorf("unknown feed %q (valid feeds: %s)", feed, strings.Join(feedNames(), ", "))
+}
+
+// validateFeedURL checks that a custom feed URL is an absolute http or https URL.
+func validateFeedURL(rawURL string) error {
+	return validateHTTPURL("feed", rawURL)
+}
+
+// validateHTTPURL checks that rawURL is an absolute http or https URL; kind
+// names it in the error, e.g. "webhook".
+func validateHTTPURL(kind, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid %s URL %q: %v", kind, rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s URL %q: must be an absolute http or https URL", kind, rawURL)
+	}
+	return nil
+}
+
+// feedPeriodDescriptions phrases each feed period for the report header.
+var feedPeriodDescriptions = map[string]string{
+	"hour":  "in the last hour",
+	"day":   "in the last day",
+	"week":  "in the last 7 days",
+	"month": "in the last 30 days",
+}
+
+// feedPeriodDurations is how far back each feed period reaches.
+var feedPeriodDurations = map[string]time.Duration{
+	"hour":  time.Hour,
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+// longestFeedPeriod returns the longest period among comma-separated feed
+// names, or "" when none names a known period.
+func longestFeedPeriod(feed string) string {
+	longest := -1
+	for _, name := range strings.Split(feed, ",") {
+		name = strings.TrimSpace(name)
+		period := name[strings.LastIndex(name, "_")+1:]
+		for i, p := range feedPeriods {
+			if p == period && i > longest {
+				longest = i
+			}
+		}
+	}
+	if longest < 0 {
+		return ""
+	}
+	return feedPeriods[longest]
+}
+
+// describeFeedPeriod returns the time window covered by a feed name. For
+// several comma-separated feeds it is the longest of their periods.
+func describeFeedPeriod(feed string) string {
+	if feed == "" {
+		return "in the supplied data"
+	}
+	return feedPeriodDescriptions[longestFeedPeriod(feed)]
+}
+
+// feedWindow returns how far back a feed reaches. Custom feeds are assumed
+// to reach back a month, like the longest USGS feeds.
+func feedWindow(feed string) time.Duration {
+	if period := longestFeedPeriod(feed); period != "" {
+		return feedPeriodDurations[period]
+	}
+	return feedPeriodDurations["month"]
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// alertLevels ranks the PAGER alert levels. Events without an alert rank
+// below green.
+var alertLevels = map[string]int{
+	"":       0,
+	"green":  1,
+	"yellow": 2,
+	"orange": 3,
+	"red":    4,
+}
+
+// reviewStatuses are the values of the USGS status property: automatic
+// solutions are later reviewed by a seismologist, or deleted.
+var reviewStatuses = []string{"automatic", "reviewed", "deleted"}
+
+// validStatus reports whether status, in any case, is one of reviewStatuses.
+func validStatus(status string) bool {
+	return containsFold(reviewStatuses, status)
+}
+
+// containsFold reports whether s is in list, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether an earthquake passes every filter in opts.
+func (opts options) matches(quake QuakeRecord) bool {
+	// An unknown magnitude only passes when no magnitude bound is set, and
+	// cannot be ranked by -top
+	if quake.unknownMag {
+		if opts.minimumMagnitude != 0 || !math.IsInf(opts.maximumMagnitude, 1) || opts.top > 0 {
+			return false
+		}
+	} else if quake.Mag < opts.minimumMagnitude || quake.Mag > opts.maximumMagnitude {
+		return false
+	}
+
+	if opts.radius > 0 && (quake.Distance == nil || *quake.Distance > opts.radius) {
+		return false
+	}
+
+	if opts.bbox != nil && (!quake.hasLocation || !opts.bbox.Contains(quake.Latitude, quake.Longitude)) {
+		return false
+	}
+
+	// Events without a depth cannot satisfy a depth bound
+	if opts.minimumDepth != nil && (!quake.hasDepth || quake.Depth < *opts.minimumDepth) {
+		return false
+	}
+
+	if opts.maximumDepth != nil && (!quake.hasDepth || quake.Depth > *opts.maximumDepth) {
+		return false
+	}
+
+	if quake.Sig < opts.minimumSig {
+		return false
+	}
+
+	if opts.minimumFelt > 0 && (quake.Felt == nil || *quake.Felt < opts.minimumFelt) {
+		return false
+	}
+
+	if opts.tsunamiOnly && !quake.Tsunami {
+		return false
+	}
+
+	if alertLevels[quake.Alert] < alertLevels[opts.minimumAlert] {
+		return false
+	}
+
+	if opts.status != "" && !strings.EqualFold(quake.Status, opts.status) {
+		return false
+	}
+
+	if len(opts.networks) > 0 && !containsFold(opts.networks, quake.Net) {
+		return false
+	}
+
+	if !opts.since.IsZero() && quake.Time.Before(opts.since) {
+		return false
+	}
+
+	if !opts.until.IsZero() && quake.Time.After(opts.until) {
+		return false
+	}
+
+	if !opts.updatedSince.IsZero() && quake.Updated.Before(opts.updatedSince) {
+		return false
+	}
+
+	if opts.place != "" && !strings.Contains(strings.ToLower(quake.Place), strings.ToLower(opts.place)) {
+		return false
+	}
+
+	if opts.placeRegex != nil && !opts.placeRegex.MatchString(quake.Place) {
+		return false
+	}
+
+	if opts.filter != nil && !opts.filter(quake) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestOptionsMatches(t *testing.T) {
+	base := options{maximumMagnitude: math.Inf(1)}
+	felt := 25
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	thirty := 30.0
+	crustal := QuakeRecord{Depth: 10, hasDepth: true}
+	deep := QuakeRecord{Depth: 550, hasDepth: true}
+
+	tests := []struct {
+		name     string
+		opts     func(o *options)
+		quake    QuakeRecord
+		expected bool
+	}{
+		{"no filters", func(o *options) {}, QuakeRecord{Mag: 2.1}, true},
+		{"minimum is inclusive", func(o *options) { o.minimumMagnitude = 5 }, QuakeRecord{Mag: 5}, true},
+		{"below minimum", func(o *options) { o.minimumMagnitude = 5 }, QuakeRecord{Mag: 4.9}, false},
+		{"above maximum", func(o *options) { o.maximumMagnitude = 5 }, QuakeRecord{Mag: 5.1}, false},
+		{"shallower than maximum depth", func(o *options) { o.maximumDepth = &thirty }, crustal, true},
+		{"deeper than maximum depth", func(o *options) { o.maximumDepth = &thirty }, deep, false},
+		{"deeper than minimum depth", func(o *options) { o.minimumDepth = &thirty }, deep, true},
+		{"shallower than minimum depth", func(o *options) { o.minimumDepth = &thirty }, crustal, false},
+		{"depth bound without depth", func(o *options) { o.maximumDepth = &thirty }, QuakeRecord{}, false},
+		{"unknown magnitude without bounds", func(o *options) {}, QuakeRecord{unknownMag: true}, true},
+		{"unknown magnitude with a minimum", func(o *options) { o.minimumMagnitude = 5 }, QuakeRecord{unknownMag: true}, false},
+		{"unknown magnitude with a maximum", func(o *options) { o.maximumMagnitude = 5 }, QuakeRecord{unknownMag: true}, false},
+		{"significance at threshold", func(o *options) { o.minimumSig = 600 }, QuakeRecord{Sig: 600}, true},
+		{"significance below threshold", func(o *options) { o.minimumSig = 600 }, QuakeRecord{Sig: 599}, false},
+		{"felt reports", func(o *options) { o.minimumFelt = 10 }, QuakeRecord{Felt: &felt}, true},
+		{"felt reports missing", func(o *options) { o.minimumFelt = 10 }, QuakeRecord{}, false},
+		{"tsunami only", func(o *options) { o.tsunamiOnly = true }, QuakeRecord{Mag: 7}, false},
+		{"tsunami flagged", func(o *options) { o.tsunamiOnly = true }, QuakeRecord{Mag: 7, Tsunami: true}, true},
+		{"alert at threshold", func(o *options) { o.minimumAlert = "yellow" }, QuakeRecord{Alert: "yellow"}, true},
+		{"alert above threshold", func(o *options) { o.minimumAlert = "yellow" }, QuakeRecord{Alert: "red"}, true},
+		{"alert below threshold", func(o *options) { o.minimumAlert = "yellow" }, QuakeRecord{Alert: "green"}, false},
+		{"missing alert", func(o *options) { o.minimumAlert = "green" }, QuakeRecord{}, false},
+		{"reviewed status", func(o *options) { o.status = "reviewed" }, QuakeRecord{Status: "reviewed"}, true},
+		{"status ignores case", func(o *options) { o.status = "Reviewed" }, QuakeRecord{Status: "reviewed"}, true},
+		{"automatic status", func(o *options) { o.status = "reviewed" }, QuakeRecord{Status: "automatic"}, false},
+		{"missing status", func(o *options) { o.status = "reviewed" }, QuakeRecord{}, false},
+		{"listed network", func(o *options) { o.networks = []string{"ci", "nc"} }, QuakeRecord{Net: "NC"}, true},
+		{"other network", func(o *options) { o.networks = []string{"ci", "nc"} }, QuakeRecord{Net: "us"}, false},
+		{"top skips unknown magnitude", func(o *options) { o.top = 3 }, QuakeRecord{unknownMag: true}, false},
+		{"since is inclusive", func(o *options) { o.since = noon }, QuakeRecord{Time: noon}, true},
+		{"before since", func(o *options) { o.since = noon }, QuakeRecord{Time: noon.Add(-time.Second)}, false},
+		{"until is inclusive", func(o *options) { o.until = noon }, QuakeRecord{Time: noon}, true},
+		{"after until", func(o *options) { o.until = noon }, QuakeRecord{Time: noon.Add(time.Second)}, false},
+		{"updated since", func(o *options) { o.updatedSince = noon }, QuakeRecord{Time: noon.Add(-time.Hour), Updated: noon}, true},
+		{"not updated since", func(o *options) { o.updatedSince = noon }, QuakeRecord{Time: noon.Add(-time.Hour), Updated: noon.Add(-time.Minute)}, false},
+		{"place ignores case", func(o *options) { o.place = "japan" }, QuakeRecord{Place: "100 km E of Miyako, Japan"}, true},
+		{"place mismatch", func(o *options) { o.place = "japan" }, QuakeRecord{Place: "10 km N of Hualien City, Taiwan"}, false},
+		{"place regex", func(o *options) { o.placeRegex = regexp.MustCompile("CA|Nevada") }, QuakeRecord{Place: "5 km W of Cobb, CA"}, true},
+		{"place regex mismatch", func(o *options) { o.placeRegex = regexp.MustCompile("CA|Nevada") }, QuakeRecord{Place: "Central Alaska"}, false},
+	}
+
+	for _, tt := range tests {
+		opts := base
+		tt.opts(&opts)
+		if got := opts.matches(tt.quake); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
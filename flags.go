@@ -0,0 +1,813 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// options holds the command-line configuration.
+type options struct {
+	// showVersion prints the build version instead of running
+	showVersion bool
+	// listFields prints the fields of an earthquake instead of running
+	listFields bool
+	// completion is the -completion script to print instead of running
+	completion string
+
+	// Program will display Earthquakes with minimumMagnitude <= magnitude <= maximumMagnitude
+	minimumMagnitude float64
+	maximumMagnitude float64
+
+	// feed is the USGS summary feed name and url the address it resolves to;
+	// feed is empty when a custom URL is given with -url or data is read
+	// from inputPath instead. When feed lists several comma-separated feeds,
+	// url is the first and feedURLs holds them all
+	feed      string
+	url       string
+	feedURLs  []string
+	inputPath string
+
+	// near is set when -near gives a reference point; radius (km) then
+	// limits results to earthquakes around it, zero meaning no limit
+	near          bool
+	nearLatitude  float64
+	nearLongitude float64
+	radius        float64
+	// bearing prints the direction from the -near point as well
+	bearing bool
+
+	// bbox limits results to a region when set
+	bbox *BoundingBox
+	// region is the -region preset bbox was taken from, if any
+	region string
+
+	// minimumDepth and maximumDepth bound the depth in km when set
+	minimumDepth *float64
+	maximumDepth *float64
+
+	// minimumSig is the lowest USGS significance score shown
+	minimumSig int
+
+	// minimumFelt is the lowest number of "Did You Feel It?" reports shown
+	minimumFelt int
+
+	// tsunamiOnly keeps only events flagged for tsunami potential
+	tsunamiOnly bool
+
+	// minimumAlert is the lowest PAGER alert level shown; empty shows all
+	minimumAlert string
+
+	// status keeps only events with this review status; empty shows all
+	status string
+
+	// networks keeps only events located by one of these seismic networks;
+	// empty shows all
+	networks []string
+
+	// place keeps events whose place contains it, ignoring case; placeRegex
+	// keeps events whose place matches it
+	place      string
+	placeRegex *regexp.Regexp
+	// filter is the -filter expression, if any
+	filter filter
+
+	// since and until bound the event time; a zero time leaves that side open
+	since time.Time
+	until time.Time
+
+	// updatedSince keeps events revised at or after it when set
+	updatedSince time.Time
+
+	// sort is the sort key; empty keeps feed order
+	sort string
+
+	// limit caps how many earthquakes are printed; zero means unlimited
+	limit int
+	// top ranks the strongest matches, setting sort and limit; zero is off
+	top int
+
+	// location is the time zone used by the text report
+	location *time.Location
+	// locationErr is why -tz fell back to UTC; warnTimezone logs it once
+	// logging is set up
+	locationErr error
+
+	// relative adds how long ago each earthquake happened to the text report
+	relative bool
+
+	// groupByRegion groups the text report under region headers
+	groupByRegion bool
+
+	// cluster groups the text report into clusters of earthquakes within
+	// clusterRadius km and clusterWindow of each other
+	cluster       bool
+	clusterRadius float64
+	clusterWindow time.Duration
+
+	// geohash adds each epicenter's geohash, of geohashPrecision
+	// characters, to the text report
+	geohash          bool
+	geohashPrecision int
+
+	// energy adds estimated energy releases to the text report
+	energy bool
+
+	// histogram adds a magnitude histogram to the text report
+	histogram bool
+
+	// trend adds the number of earthquakes per day to the text report,
+	// drawn as a sparkline with sparkline
+	trend     bool
+	sparkline bool
+
+	// asciiMap adds an ASCII world map of the epicenters to the text report
+	asciiMap bool
+
+	// color is the -color mode; colorize is resolved from it once the
+	// output is known
+	color    string
+	colorize bool
+
+	// coordinates is the -coords style for the text report
+	coordinates string
+
+	// units is the -units system for depths and distances in the text report
+	units string
+
+	// maps adds a Google Maps link to each earthquake in the text report
+	maps bool
+
+	// format selects how earthquakes are printed
+	format string
+
+	// template is the parsed -template file, used when format is
+	// formatTemplate
+	template *template.Template
+
+	// countOnly prints just the number of matching earthquakes
+	countOnly bool
+
+	// outputPath is the file the report is written to; empty means stdout
+	outputPath string
+
+	// timeout bounds each request to USGS; zero means no timeout
+	timeout time.Duration
+
+	// retries is how many times a failed fetch is retried
+	retries int
+
+	// insecure skips TLS certificate verification and caCertPath names a
+	// PEM file of extra CAs to trust, for TLS-terminating proxies
+	insecure   bool
+	caCertPath string
+	// proxy is the -proxy server for every request, overriding the
+	// HTTP(S)_PROXY environment variables; nil uses them
+	proxy *url.URL
+
+	// strict fails on a feature with an unexpected geometry instead of
+	// skipping it with a warning
+	strict bool
+
+	// cacheTTL is how long a cached feed is reused; zero disables caching
+	cacheTTL time.Duration
+
+	// watch is the poll interval in watch mode; zero runs once
+	watch time.Duration
+
+	// tui browses the earthquakes in an interactive terminal UI, refreshed
+	// every watch
+	tui bool
+
+	// diff reports what changed since the previous -diff run instead of
+	// listing the earthquakes
+	diff bool
+
+	// serveAddr is where -serve listens for API requests; empty runs the
+	// command line once
+	serveAddr string
+
+	// columns are the fields of the tabular formats, in order
+	columns []column
+
+	// dbPath is a SQLite database that matched earthquakes are upserted
+	// into, building a history beyond the feed's window
+	dbPath string
+
+	// statePath is where watch mode persists the IDs it has reported;
+	// empty keeps them in memory only
+	statePath string
+
+	// webhookURL receives each new earthquake in watch or diff mode as a
+	// JSON POST
+	webhookURL string
+	// slackURL is a Slack incoming webhook that receives each new
+	// earthquake in watch or diff mode as a formatted message
+	slackURL string
+	// slackMagnitude is the magnitude from which new earthquakes are posted
+	// to slackURL; zero posts every one
+	slackMagnitude float64
+
+	// metricsAddr is where watch mode serves Prometheus metrics; empty
+	// disables the server
+	metricsAddr string
+
+	// logFormat is the -log-format for diagnostics on stderr; verbose adds
+	// debug records
+	logFormat string
+	verbose   bool
+
+	// debug dumps each raw feed response to stderr and implies verbose
+	debug bool
+
+	// notifyMagnitude is the magnitude from which printed earthquakes raise a
+	// desktop notification; zero disables notifications
+	notifyMagnitude float64
+}
+
+// stdinPath is the input path that reads GeoJSON from stdin.
+const stdinPath = "-"
+
+// defaultTimeout is the HTTP client timeout used when -timeout is not given.
+const defaultTimeout = 15 * time.Second
+
+// parseFlags parses the command-line arguments (without the program name).
+// A bare positional magnitude is still accepted in place of -min.
+func parseFlags(args []string) (options, error) {
+	return parseArgs(args, os.Stderr, true)
+}
+
+// parseArgs is parseFlags with usage and parse errors written to output.
+// userDefaults reads the config file and the environment, which the -serve
+// query parameters leave out.
+func parseArgs(args []string, output io.Writer, userDefaults bool) (options, error) {
+	var opts options
+	var jsonOutput bool
+	var days int
+	var class string
+	var completion, networks, near, bbox, proxy, columns, timezone, placeRegex, filterExpr, since, until, updatedSince, templatePath string
+
+	fs := flag.NewFlagSet("eqk", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: eqk [options] [minimum magnitude | -]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Earthquakes are listed in the order USGS returns them unless -sort is given;")
+		fmt.Fprintln(fs.Output(), "-sort time lists the most recent first.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Exit status is 0 when earthquakes matched, 1 when none matched")
+		fmt.Fprintln(fs.Output(), "and 2 on errors.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Options on the command line override the environment variables")
+		fmt.Fprintln(fs.Output(), "EQK_MIN, EQK_FEED, EQK_FORMAT, EQK_TIMEZONE (for -tz) and EQK_URL,")
+		fmt.Fprintln(fs.Output(), "which override the config file, which overrides the defaults below.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Options:")
+		printVisibleDefaults(fs)
+	}
+
+	fs.BoolVar(&opts.showVersion, "version", false, "print the version, commit and build date, then exit")
+	fs.String("config", "", "read default options from this YAML file instead of ~/.config/eqk/config.yaml")
+	fs.BoolVar(&opts.listFields, "list-fields", false, "list the fields -columns, -sort and -template can use, then exit")
+	fs.StringVar(&completion, "completion", "", "print a completion script for "+strings.Join(completionShells, ", ")+", then exit")
+	fs.Float64Var(&opts.minimumMagnitude, "min", 0, "minimum magnitude (inclusive)")
+	fs.Float64Var(&opts.maximumMagnitude, "max", math.Inf(1), "maximum magnitude (inclusive)")
+	fs.StringVar(&opts.feed, "feed", defaultFeed, "USGS feed as <class>_<period>, e.g. 4.5_week; separate several with commas")
+	fs.IntVar(&days, "days", 0, "select the USGS feed covering this many days: "+strings.Join(feedDayChoices(), ", "))
+	fs.StringVar(&class, "class", "", "with -days, the feed's magnitude class: "+strings.Join(feedClasses, ", ")+" (default significant)")
+	fs.StringVar(&opts.url, "url", "", "custom feed URL, used verbatim instead of -feed")
+	fs.StringVar(&near, "near", "", "reference point as \"lat,lon\"; prints each earthquake's distance from it")
+	fs.BoolVar(&opts.bearing, "bearing", false, "with -near, also print the compass direction to each earthquake")
+	fs.Float64Var(&opts.radius, "radius", 0, "only show earthquakes within this many km of -near")
+	fs.StringVar(&bbox, "bbox", "", "only show earthquakes inside \"minLon,minLat,maxLon,maxLat\"")
+	fs.StringVar(&opts.region, "region", "", "only show earthquakes inside this preset area, e.g. california or japan")
+	fs.Func("min-depth", "only show earthquakes at least this many km deep", depthFlag(&opts.minimumDepth))
+	fs.Func("max-depth", "only show earthquakes at most this many km deep, e.g. 30 for crustal events", depthFlag(&opts.maximumDepth))
+	fs.IntVar(&opts.minimumSig, "min-sig", 0, "only show events with at least this USGS significance score")
+	fs.IntVar(&opts.minimumFelt, "min-felt", 0, "only show events with at least this many \"Did You Feel It?\" reports")
+	fs.BoolVar(&opts.tsunamiOnly, "tsunami-only", false, "only show events flagged for tsunami potential")
+	fs.StringVar(&opts.status, "status", "", "only show events with this review status: "+strings.Join(reviewStatuses, ", "))
+	fs.StringVar(&networks, "net", "", "only show events from this seismic network, e.g. us or ci; separate several with commas")
+	fs.StringVar(&opts.minimumAlert, "min-alert", "", "only show events with at least this PAGER alert: green, yellow, orange or red")
+	fs.StringVar(&opts.place, "place", "", "only show events whose place contains this text, ignoring case")
+	fs.StringVar(&filterExpr, "filter", "", "only show events matching this expression, e.g. 'mag >= 5 && place contains \"Alaska\"'")
+	fs.StringVar(&placeRegex, "place-regex", "", "only show events whose place matches this regular expression, e.g. \"CA|Nevada\"")
+	fs.StringVar(&since, "since", "", "only show events at or after this RFC3339 time, or this long ago, e.g. 24h")
+	fs.StringVar(&until, "until", "", "only show events at or before this RFC3339 time, or this long ago, e.g. 6h")
+	fs.StringVar(&updatedSince, "updated-since", "", "only show events USGS revised at or after this RFC3339 time, or this long ago, e.g. 1h")
+	fs.StringVar(&opts.sort, "sort", "", "sort by "+strings.Join(sortKeys, ", ")+" (default feed order)")
+	fs.IntVar(&opts.limit, "limit", 0, "print at most this many earthquakes (0 for all)")
+	fs.IntVar(&opts.top, "top", 0, "print only the N strongest matching earthquakes, ranked by magnitude")
+	fs.StringVar(&timezone, "tz", "UTC", "time zone for printed times: an IANA name like America/Sao_Paulo, or local")
+	fs.BoolVar(&opts.relative, "relative", false, "show how long ago each earthquake happened, e.g. \"3h 12m ago\", after its time")
+	fs.BoolVar(&opts.groupByRegion, "group-by-region", false, "group earthquakes by the region at the end of their place name")
+	fs.BoolVar(&opts.energy, "energy", false, "print each earthquake's estimated energy release and the total, in joules")
+	fs.BoolVar(&opts.cluster, "cluster", false, "group nearby earthquakes into clusters, printing each mainshock with its aftershock count")
+	fs.Float64Var(&opts.clusterRadius, "cluster-radius", defaultClusterRadius, "with -cluster, link earthquakes within this many km")
+	fs.DurationVar(&opts.clusterWindow, "cluster-window", defaultClusterWindow, "with -cluster, link earthquakes within this time of each other")
+	fs.BoolVar(&opts.histogram, "hist", false, "print a histogram of magnitudes after the list")
+	fs.BoolVar(&opts.trend, "trend", false, "print the number of earthquakes per day (in -tz) over the feed window after the list")
+	fs.BoolVar(&opts.sparkline, "sparkline", false, "with -trend, draw the daily counts as a one-line sparkline instead of bars")
+	fs.BoolVar(&opts.asciiMap, "map", false, "print an ASCII world map of the epicenters after the list")
+	fs.StringVar(&opts.color, "color", colorAuto, "color magnitudes by severity: auto (only on a terminal), always or never")
+	fs.StringVar(&opts.coordinates, "coords", coordinatesDecimal, "coordinate style: decimal or dms (degrees, minutes, seconds)")
+	fs.StringVar(&opts.units, "units", unitsMetric, "units for depths and distances in the text report: metric (km) or imperial (mi)")
+	fs.BoolVar(&opts.geohash, "geohash", false, "print a geohash of each epicenter")
+	fs.IntVar(&opts.geohashPrecision, "geohash-precision", defaultGeohashPrecision, fmt.Sprintf("with -geohash, the number of geohash characters (1-%d)", maxGeohashPrecision))
+	fs.BoolVar(&opts.maps, "maps", false, "print a Google Maps link for each earthquake")
+	fs.StringVar(&opts.inputPath, "file", "", "read GeoJSON from this file instead of fetching it (- for stdin)")
+	fs.StringVar(&opts.format, "format", formatText, "output format: "+strings.Join(outputFormats, ", "))
+	fs.StringVar(&columns, "columns", defaultColumns, "with -format csv or table, the columns to print, in order (table default "+defaultTableColumns+")")
+	fs.StringVar(&templatePath, "template", "", "print each earthquake with this Go text/template file instead of -format")
+	fs.BoolVar(&opts.countOnly, "count-only", false, "print only the number of matching earthquakes")
+	fs.BoolVar(&opts.countOnly, "q", false, "shorthand for -count-only")
+	fs.StringVar(&opts.dbPath, "db", "", "also upsert the matching earthquakes into this SQLite database, keeping history")
+	fs.StringVar(&opts.outputPath, "out", "", "write the report to this file instead of stdout")
+	fs.BoolVar(&jsonOutput, "json", false, "print earthquakes as a JSON array (same as -format json)")
+	fs.DurationVar(&opts.timeout, "timeout", defaultTimeout, "HTTP request timeout, e.g. 30s (0 disables it)")
+	fs.DurationVar(&opts.watch, "watch", 0, "re-fetch the feed at this interval, e.g. 60s, printing only new earthquakes")
+	fs.BoolVar(&opts.tui, "tui", false, "browse the earthquakes in an interactive terminal UI, refreshed every -watch (default 1m)")
+	fs.BoolVar(&opts.diff, "diff", false, "print new, revised and dropped earthquakes since the last -diff run")
+	fs.StringVar(&opts.serveAddr, "serve", "", "serve earthquakes as JSON at /quakes on this address, e.g. :8080")
+	fs.StringVar(&opts.statePath, "state", "", "remember reported earthquakes in this JSON file across runs; without -watch, report and alert on the new ones once")
+	fs.StringVar(&opts.webhookURL, "webhook", "", "in -watch, -diff or -state mode, POST each new earthquake as JSON to this URL")
+	fs.StringVar(&opts.slackURL, "slack", "", "in -watch, -diff or -state mode, post each new earthquake to this Slack incoming webhook URL")
+	fs.Float64Var(&opts.slackMagnitude, "slack-min", 0, "only post earthquakes of at least this magnitude to -slack")
+	fs.StringVar(&opts.metricsAddr, "metrics", "", "in -watch mode, serve Prometheus metrics on this address, e.g. :9100")
+	fs.Float64Var(&opts.notifyMagnitude, "notify", 0, "show a desktop notification for printed earthquakes of at least this magnitude")
+	fs.BoolVar(&opts.insecure, "insecure", false, "skip TLS certificate verification (unsafe; for TLS-terminating proxies)")
+	fs.StringVar(&opts.caCertPath, "cacert", "", "also trust the CA certificates in this PEM file, e.g. a corporate proxy's")
+	fs.StringVar(&proxy, "proxy", "", "send requests through this proxy, e.g. http://proxy:3128, instead of HTTP(S)_PROXY")
+	fs.IntVar(&opts.retries, "retries", 3, "number of times to retry network errors and 5xx responses")
+	fs.StringVar(&opts.logFormat, "log-format", logFormatText, "format of diagnostics on stderr: text or json")
+	fs.BoolVar(&opts.verbose, "verbose", false, "log fetch attempts, responses and cache hits")
+	fs.BoolVar(&opts.debug, "debug", false, "like -verbose, and also dump each raw feed response to stderr")
+	fs.BoolVar(&opts.strict, "strict", false, "fail on an earthquake whose GeoJSON geometry is not a Point instead of skipping it")
+	fs.DurationVar(&opts.cacheTTL, "cache-ttl", 0, "reuse a cached copy of the feed younger than this, e.g. 5m (0 always fetches; fetched feeds are still written to the cache directory for ETags and offline use)")
+
+	// The config file and then the environment only supply defaults; flags on
+	// the command line win
+	layers := optionLayers{}
+	if userDefaults {
+		config, path, err := loadConfig(args)
+		if err != nil {
+			return options{}, err
+		}
+		if err := applyDefaults(fs, config, path, layers, configLayer); err != nil {
+			return options{}, err
+		}
+		if err := applyEnvironment(fs, layers); err != nil {
+			return options{}, err
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+
+	if completion != "" {
+		script, err := completionScript(completion, fs)
+		if err != nil {
+			return options{}, err
+		}
+		opts.completion = script
+		return opts, nil
+	}
+
+	if jsonOutput && layers.of(fs, "json") >= layers.of(fs, "format") {
+		opts.format = formatJSON
+	} else {
+		jsonOutput = false
+	}
+
+	positional := fs.Args()
+
+	// A lone "-" argument reads the feed from stdin, the same as -file -
+	if len(positional) == 1 && positional[0] == stdinPath {
+		if isFlagSet(fs, "file") {
+			return options{}, fmt.Errorf("both -file and - given; choose one input")
+		}
+		opts.inputPath = stdinPath
+		layers["file"] = commandLineLayer
+		positional = nil
+	}
+
+	if len(positional) > 1 {
+		return options{}, fmt.Errorf("unexpected arguments: %s", strings.Join(positional[1:], " "))
+	}
+
+	if len(positional) == 1 {
+		if isFlagSet(fs, "min") {
+			return options{}, fmt.Errorf("minimum magnitude given both as -min and as argument %q", positional[0])
+		}
+		n, err := strconv.ParseFloat(positional[0], 64)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid minimum magnitude %q", positional[0])
+		}
+		opts.minimumMagnitude = n
+	}
+
+	if opts.minimumMagnitude > opts.maximumMagnitude {
+		return options{}, fmt.Errorf("minimum magnitude %.1f is greater than maximum magnitude %.1f", opts.minimumMagnitude, opts.maximumMagnitude)
+	}
+
+	if near != "" {
+		lat, lon, err := parseLatLon(near)
+		if err != nil {
+			return options{}, err
+		}
+		opts.near, opts.nearLatitude, opts.nearLongitude = true, lat, lon
+	}
+
+	if opts.bearing && !opts.near {
+		return options{}, fmt.Errorf("-bearing requires -near")
+	}
+
+	if opts.geohashPrecision < 1 || opts.geohashPrecision > maxGeohashPrecision {
+		return options{}, fmt.Errorf("invalid -geohash-precision %d: must be between 1 and %d", opts.geohashPrecision, maxGeohashPrecision)
+	}
+
+	if opts.sparkline && !opts.trend {
+		return options{}, fmt.Errorf("-sparkline requires -trend")
+	}
+	if opts.cluster && opts.groupByRegion {
+		return options{}, fmt.Errorf("-cluster and -group-by-region cannot be used together")
+	}
+	if opts.clusterRadius <= 0 || opts.clusterWindow <= 0 {
+		return options{}, fmt.Errorf("-cluster-radius and -cluster-window must be positive")
+	}
+
+	if opts.radius < 0 {
+		return options{}, fmt.Errorf("invalid radius %v", opts.radius)
+	}
+	if opts.radius > 0 && !opts.near {
+		return options{}, fmt.Errorf("-radius requires -near")
+	}
+
+	if bbox != "" {
+		box, err := parseBoundingBox(bbox)
+		if err != nil {
+			return options{}, err
+		}
+		opts.bbox = &box
+	}
+
+	if opts.region != "" {
+		if bbox != "" {
+			return options{}, fmt.Errorf("-region and -bbox cannot be used together")
+		}
+		box, err := regionBoundingBox(opts.region)
+		if err != nil {
+			return options{}, err
+		}
+		opts.bbox = &box
+	}
+
+	if opts.minimumDepth != nil && opts.maximumDepth != nil && *opts.minimumDepth > *opts.maximumDepth {
+		return options{}, fmt.Errorf("minimum depth %.1f km is greater than maximum depth %.1f km", *opts.minimumDepth, *opts.maximumDepth)
+	}
+
+	if _, ok := alertLevels[opts.minimumAlert]; !ok {
+		return options{}, fmt.Errorf("unknown alert level %q (valid levels: green, yellow, orange, red)", opts.minimumAlert)
+	}
+
+	if opts.status != "" && !validStatus(opts.status) {
+		return options{}, fmt.Errorf("unknown status %q (valid statuses: %s)", opts.status, strings.Join(reviewStatuses, ", "))
+	}
+
+	if networks != "" {
+		for _, network := range strings.Split(networks, ",") {
+			network = strings.TrimSpace(network)
+			if network == "" {
+				return options{}, fmt.Errorf("invalid -net %q: empty network code", networks)
+			}
+			opts.networks = append(opts.networks, network)
+		}
+	}
+
+	if placeRegex != "" {
+		re, err := regexp.Compile(placeRegex)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid -place-regex: %v", err)
+		}
+		opts.placeRegex = re
+	}
+
+	now := time.Now()
+	if filterExpr != "" {
+		f, err := parseFilter(filterExpr, now)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid -filter: %v", err)
+		}
+		opts.filter = f
+	}
+	if since != "" {
+		t, err := parseTimeBound(since, now)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid -since: %v", err)
+		}
+		opts.since = t
+	}
+	if until != "" {
+		t, err := parseTimeBound(until, now)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid -until: %v", err)
+		}
+		opts.until = t
+	}
+	if updatedSince != "" {
+		t, err := parseTimeBound(updatedSince, now)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid -updated-since: %v", err)
+		}
+		opts.updatedSince = t
+	}
+	if !opts.since.IsZero() && !opts.until.IsZero() && opts.since.After(opts.until) {
+		return options{}, fmt.Errorf("-since %s is after -until %s", opts.since.Format(time.RFC3339), opts.until.Format(time.RFC3339))
+	}
+
+	if _, ok := quakeLess[opts.sort]; opts.sort != "" && !ok {
+		return options{}, fmt.Errorf("unknown sort key %q (valid keys: %s)", opts.sort, strings.Join(sortKeys, ", "))
+	}
+
+	opts.location, opts.locationErr = loadLocation(timezone)
+
+	if opts.limit < 0 {
+		return options{}, fmt.Errorf("invalid limit %d", opts.limit)
+	}
+
+	if opts.top != 0 {
+		if opts.top < 0 {
+			return options{}, fmt.Errorf("invalid -top %d", opts.top)
+		}
+		if opts.limit > 0 || opts.sort != "" {
+			return options{}, fmt.Errorf("-top cannot be combined with -limit or -sort")
+		}
+		if opts.cluster || opts.groupByRegion {
+			return options{}, fmt.Errorf("-top cannot be combined with -cluster or -group-by-region")
+		}
+		opts.sort, opts.limit = sortByMagnitude, opts.top
+	}
+
+	if opts.timeout < 0 {
+		return options{}, fmt.Errorf("invalid timeout %s", opts.timeout)
+	}
+
+	if opts.cacheTTL < 0 {
+		return options{}, fmt.Errorf("invalid cache TTL %s", opts.cacheTTL)
+	}
+
+	if opts.retries < 0 {
+		return options{}, fmt.Errorf("invalid number of retries %d", opts.retries)
+	}
+
+	if opts.watch < 0 {
+		return options{}, fmt.Errorf("invalid watch interval %s", opts.watch)
+	}
+
+	if opts.color != colorAuto && opts.color != colorAlways && opts.color != colorNever {
+		return options{}, fmt.Errorf("invalid -color value %q (valid values: auto, always, never)", opts.color)
+	}
+
+	if opts.coordinates != coordinatesDecimal && opts.coordinates != coordinatesDMS {
+		return options{}, fmt.Errorf("invalid -coords value %q (valid values: decimal, dms)", opts.coordinates)
+	}
+
+	if opts.logFormat != logFormatText && opts.logFormat != logFormatJSON {
+		return options{}, fmt.Errorf("invalid -log-format value %q (valid values: text, json)", opts.logFormat)
+	}
+
+	if opts.units != unitsMetric && opts.units != unitsImperial {
+		return options{}, fmt.Errorf("invalid -units value %q (valid values: metric, imperial)", opts.units)
+	}
+
+	if !validFormat(opts.format) {
+		return options{}, fmt.Errorf("unknown output format %q (valid formats: %s)", opts.format, strings.Join(outputFormats, ", "))
+	}
+
+	if opts.format == formatTable && layers.of(fs, "columns") == defaultLayer {
+		columns = defaultTableColumns
+	}
+	tableColumns, err := parseColumns(columns)
+	if err != nil {
+		return options{}, err
+	}
+	opts.columns = tableColumns
+	if isFlagSet(fs, "columns") && opts.format != formatCSV && opts.format != formatTable {
+		return options{}, fmt.Errorf("-columns requires -format csv or table")
+	}
+
+	formatLayer := layers.of(fs, "format")
+	if jsonOutput {
+		formatLayer = layers.of(fs, "json")
+	}
+	if templatePath != "" && formatLayer > layers.of(fs, "template") {
+		templatePath = ""
+	}
+	if templatePath != "" {
+		if formatLayer == commandLineLayer {
+			return options{}, fmt.Errorf("-template cannot be combined with -format or -json")
+		}
+		tmpl, err := loadTemplate(templatePath, opts.location)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid -template: %v", err)
+		}
+		opts.format, opts.template = formatTemplate, tmpl
+	}
+
+	// -file, -url and -feed (or -days and -class) each choose where the
+	// earthquakes come from. The one from the strongest layer wins; on the
+	// command line they conflict, and in the config file or the environment
+	// -file beats -url, which beats -days and -class, which beat -feed.
+	feedLayer, daysLayer := layers.of(fs, "feed"), max(layers.of(fs, "days"), layers.of(fs, "class"))
+	if daysLayer > defaultLayer {
+		if daysLayer == commandLineLayer && feedLayer == commandLineLayer {
+			return options{}, fmt.Errorf("-days and -class cannot be combined with -feed")
+		}
+		if daysLayer >= feedLayer {
+			feed, err := feedForDays(days, class)
+			if err != nil {
+				return options{}, err
+			}
+			opts.feed, feedLayer = feed, daysLayer
+		}
+	}
+
+	fileLayer, urlLayer := layers.of(fs, "file"), layers.of(fs, "url")
+	if opts.inputPath == "" {
+		fileLayer = defaultLayer
+	}
+	if opts.url == "" {
+		urlLayer = defaultLayer
+	}
+	if fileLayer == commandLineLayer && (feedLayer == commandLineLayer || urlLayer == commandLineLayer) {
+		return options{}, fmt.Errorf("-file cannot be combined with -feed, -days, -class or -url")
+	}
+	if urlLayer == commandLineLayer && feedLayer == commandLineLayer {
+		return options{}, fmt.Errorf("-feed, -days and -class cannot be used with -url")
+	}
+
+	if fileLayer > defaultLayer && fileLayer >= urlLayer && fileLayer >= feedLayer {
+		opts.url, opts.feed = "", ""
+	} else if urlLayer > defaultLayer && urlLayer >= feedLayer {
+		if err := validateFeedURL(opts.url); err != nil {
+			return options{}, err
+		}
+		opts.inputPath, opts.feed = "", ""
+	} else {
+		opts.inputPath, opts.url = "", ""
+		for _, feed := range strings.Split(opts.feed, ",") {
+			u, err := feedURL(strings.TrimSpace(feed))
+			if err != nil {
+				return options{}, err
+			}
+			opts.feedURLs = append(opts.feedURLs, u)
+		}
+		opts.url = opts.feedURLs[0]
+	}
+
+	if proxy != "" {
+		u, err := parseProxyURL(proxy)
+		if err != nil {
+			return options{}, err
+		}
+		opts.proxy = u
+	}
+
+	if opts.serveAddr != "" && (opts.watch > 0 || opts.inputPath != "") {
+		return options{}, fmt.Errorf("-serve cannot be combined with -watch or -file")
+	}
+
+	if opts.metricsAddr != "" && opts.watch == 0 {
+		return options{}, fmt.Errorf("-metrics requires -watch")
+	}
+	if opts.statePath != "" && (opts.diff || opts.serveAddr != "" || opts.tui) {
+		return options{}, fmt.Errorf("-state cannot be combined with -diff, -serve or -tui")
+	}
+	if opts.webhookURL != "" {
+		if opts.watch == 0 && !opts.diff && opts.statePath == "" {
+			return options{}, fmt.Errorf("-webhook requires -watch, -diff or -state")
+		}
+		if err := validateHTTPURL("webhook", opts.webhookURL); err != nil {
+			return options{}, err
+		}
+	}
+	if opts.slackURL != "" {
+		if opts.watch == 0 && !opts.diff && opts.statePath == "" {
+			return options{}, fmt.Errorf("-slack requires -watch, -diff or -state")
+		}
+		if err := validateHTTPURL("Slack webhook", opts.slackURL); err != nil {
+			return options{}, err
+		}
+	}
+	if opts.slackMagnitude != 0 && opts.slackURL == "" {
+		return options{}, fmt.Errorf("-slack-min requires -slack")
+	}
+
+	if opts.tui {
+		if opts.serveAddr != "" || opts.diff || opts.outputPath != "" || opts.inputPath == stdinPath {
+			return options{}, fmt.Errorf("-tui cannot be combined with -serve, -diff, -out or reading stdin")
+		}
+		if opts.countOnly || opts.format != formatText {
+			return options{}, fmt.Errorf("-tui only supports the %s format", formatText)
+		}
+	}
+
+	if opts.diff {
+		if opts.watch > 0 || opts.serveAddr != "" {
+			return options{}, fmt.Errorf("-diff cannot be combined with -watch or -serve")
+		}
+		if opts.countOnly || opts.format != formatText {
+			return options{}, fmt.Errorf("-diff only supports the %s format", formatText)
+		}
+	}
+
+	if opts.watch > 0 || opts.statePath != "" {
+		// A -state run without -watch is a single watch poll
+		mode := "-watch"
+		if opts.watch == 0 {
+			mode = "-state"
+		}
+		if opts.countOnly {
+			return options{}, fmt.Errorf("%s cannot be combined with -count-only", mode)
+		}
+		// -tui only takes its refresh interval from -watch and does limit
+		if opts.limit > 0 && !opts.tui {
+			return options{}, fmt.Errorf("%s cannot be combined with -limit or -top", mode)
+		}
+		if opts.format != formatText && opts.format != formatJSONL {
+			return options{}, fmt.Errorf("%s only supports the %s and %s formats", mode, formatText, formatJSONL)
+		}
+		if opts.watch > 0 && opts.inputPath == stdinPath {
+			return options{}, fmt.Errorf("-watch cannot read from stdin")
+		}
+	}
+
+	return opts, nil
+}
+
+// depthFlag parses a -min-depth or -max-depth value into *bound.
+func depthFlag(bound **float64) func(string) error {
+	return func(value string) error {
+		depth, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid depth %q", value)
+		}
+		*bound = &depth
+		return nil
+	}
+}
+
+// isFlagSet reports whether the named flag was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// parseTimeBound parses a -since or -until value: an RFC3339 timestamp, or a
+// duration such as 24h meaning that long before now.
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 time nor a duration like 24h", value)
+	}
+	return now.Add(-d), nil
+}
+
+// loadLocation resolves a -tz value, falling back to UTC with the error when
+// the zone cannot be loaded.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "local" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC, err
+	}
+	return location, nil
+}
+
+// warnTimezone warns when -tz fell back to UTC. It is kept out of parseArgs
+// so the warning follows -log-format.
+func warnTimezone(opts options) {
+	if opts.locationErr != nil {
+		slog.Warn("Unknown time zone, using UTC", "err", opts.locationErr)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseFlagsDefaults(t *testing.T) {
+	opts, err := parseFlags(nil)
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	if opts.minimumMagnitude != 0 {
+		t.Errorf("Expected minimum magnitude 0, got %v", opts.minimumMagnitude)
+	}
+	if !math.IsInf(opts.maximumMagnitude, 1) {
+		t.Errorf("Expected unlimited maximum magnitude, got %v", opts.maximumMagnitude)
+	}
+	if opts.format != formatText {
+		t.Errorf("Expected format %q, got %q", formatText, opts.format)
+	}
+	if opts.location != time.UTC {
+		t.Errorf("Expected UTC location, got %v", opts.location)
+	}
+	if expected := feedBaseURL + defaultFeed + ".geojson"; opts.url != expected {
+		t.Errorf("Expected default feed URL %q, got %q", expected, opts.url)
+	}
+}
+
+func TestParseFlagsFeed(t *testing.T) {
+	opts, err := parseFlags([]string{"-feed", "4.5_week"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	expected := "https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/4.5_week.geojson"
+	if opts.url != expected {
+		t.Errorf("Expected feed URL %q, got %q", expected, opts.url)
+	}
+}
+
+func TestParseFlagsDays(t *testing.T) {
+	tests := []struct {
+		args []string
+		feed string
+	}{
+		{[]string{"-days", "7", "-class", "4.5"}, "4.5_week"},
+		{[]string{"-days", "1"}, "significant_day"},
+		{[]string{"-class", "all"}, "all_month"},
+	}
+
+	for _, test := range tests {
+		opts, err := parseFlags(test.args)
+		if err != nil {
+			t.Fatalf("parseFlags(%v) returned an error: %v", test.args, err)
+		}
+		if opts.feed != test.feed || opts.url != feedBaseURL+test.feed+".geojson" {
+			t.Errorf("parseFlags(%v): expected feed %q, got %q (%s)", test.args, test.feed, opts.feed, opts.url)
+		}
+	}
+}
+
+func TestParseFlagsMultipleFeeds(t *testing.T) {
+	opts, err := parseFlags([]string{"-feed", "significant_week, 4.5_day"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	expected := []string{feedBaseURL + "significant_week.geojson", feedBaseURL + "4.5_day.geojson"}
+	if len(opts.feedURLs) != 2 || opts.feedURLs[0] != expected[0] || opts.feedURLs[1] != expected[1] {
+		t.Errorf("Expected feed URLs %q, got %q", expected, opts.feedURLs)
+	}
+	if opts.url != expected[0] {
+		t.Errorf("Expected the first feed URL %q, got %q", expected[0], opts.url)
+	}
+	if got := describeFeedPeriod(opts.feed); got != "in the last 7 days" {
+		t.Errorf("Expected the longest feed period, got %q", got)
+	}
+}
+
+func TestParseFlagsNetworks(t *testing.T) {
+	opts, err := parseFlags([]string{"-net", "ci, nc"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if len(opts.networks) != 2 || opts.networks[0] != "ci" || opts.networks[1] != "nc" {
+		t.Errorf("Expected networks [ci nc], got %q", opts.networks)
+	}
+}
+
+func TestParseFlagsMagnitude(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected float64
+	}{
+		{[]string{"-min", "4.5"}, 4.5},
+		{[]string{"5"}, 5},
+		{[]string{"-max", "7", "6"}, 6},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseFlags(tt.args)
+		if err != nil {
+			t.Errorf("parseFlags(%v) returned an error: %v", tt.args, err)
+			continue
+		}
+		if opts.minimumMagnitude != tt.expected {
+			t.Errorf("parseFlags(%v): expected minimum magnitude %v, got %v", tt.args, tt.expected, opts.minimumMagnitude)
+		}
+	}
+}
+
+func TestParseFlagsURL(t *testing.T) {
+	customURL := "http://localhost:8000/feed.geojson"
+
+	opts, err := parseFlags([]string{"-url", customURL})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.url != customURL {
+		t.Errorf("Expected URL %q, got %q", customURL, opts.url)
+	}
+	if opts.feed != "" {
+		t.Errorf("Expected no feed name with a custom URL, got %q", opts.feed)
+	}
+}
+
+func TestParseFlagsTimezone(t *testing.T) {
+	opts, err := parseFlags([]string{"-tz", "America/Sao_Paulo"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.location.String() != "America/Sao_Paulo" {
+		t.Errorf("Expected America/Sao_Paulo, got %v", opts.location)
+	}
+
+	// Unknown zones fall back to UTC instead of failing
+	opts, err = parseFlags([]string{"-tz", "Mars/Olympus_Mons"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.location != time.UTC {
+		t.Errorf("Expected UTC fallback, got %v", opts.location)
+	}
+}
+
+func TestParseFlagsStdin(t *testing.T) {
+	for _, args := range [][]string{{"-"}, {"-min", "4", "-"}, {"-file", "-"}} {
+		opts, err := parseFlags(args)
+		if err != nil {
+			t.Errorf("parseFlags(%v) returned an error: %v", args, err)
+			continue
+		}
+		if opts.inputPath != stdinPath {
+			t.Errorf("parseFlags(%v): expected stdin input, got %q", args, opts.inputPath)
+		}
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-01-01T06:30:00Z", time.Date(2024, 1, 1, 6, 30, 0, 0, time.UTC)},
+		{"2024-01-01T06:30:00-03:00", time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)},
+		{"24h", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"90m", time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.value, now)
+		if err != nil {
+			t.Errorf("parseTimeBound(%q) returned an error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("parseTimeBound(%q): expected %s, got %s", tt.value, tt.expected, got)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "-1h", "2024-01-01"} {
+		if _, err := parseTimeBound(value, now); err == nil {
+			t.Errorf("parseTimeBound(%q) expected an error, got nil", value)
+		}
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	tests := [][]string{
+		{"abc"},
+		{"-min", "4", "5"},
+		{"-min", "6", "-max", "5"},
+		{"-format", "xml"},
+		{"4", "5"},
+		{"-feed", "5.0_week"},
+		{"-feed", "all_day,5.0_week"},
+		{"-sort", "place"},
+		{"-radius", "100"},
+		{"-near", "north"},
+		{"-min-alert", "purple"},
+		{"-place-regex", "(CA"},
+		{"-since", "yesterday"},
+		{"-updated-since", "recently"},
+		{"-since", "2024-01-02T00:00:00Z", "-until", "2024-01-01T00:00:00Z"},
+		{"-color", "sometimes"},
+		{"-coords", "utm"},
+		{"-units", "furlongs"},
+		{"-log-format", "xml"},
+		{"-metrics", ":9100"},
+		{"-state", "seen.json", "-diff"},
+		{"-state", "seen.json", "-format", "csv"},
+		{"-webhook", "http://localhost:8000/hook"},
+		{"-watch", "1m", "-webhook", "localhost:8000"},
+		{"-slack", "https://hooks.slack.com/services/T0/B0/x"},
+		{"-watch", "1m", "-slack-min", "5"},
+		{"-serve", ":8080", "-watch", "1m"},
+		{"-diff", "-watch", "1m"},
+		{"-watch", "1m", "-limit", "5"},
+		{"-watch", "1m", "-top", "3"},
+		{"-state", "seen.json", "-limit", "5"},
+		{"-diff", "-format", "json"},
+		{"-sparkline"},
+		{"-bearing"},
+		{"-tui", "-format", "json"},
+		{"-tui", "-diff"},
+		{"-top", "-1"},
+		{"-status", "vetted"},
+		{"-net", "ci,,nc"},
+		{"-top", "3", "-limit", "5"},
+		{"-top", "3", "-sort", "time"},
+		{"-days", "3"},
+		{"-region", "atlantis"},
+		{"-cluster", "-group-by-region"},
+		{"-geohash-precision", "13"},
+		{"-proxy", "ftp://proxy:21"},
+		{"-format", "csv", "-columns", "place,magnitude"},
+		{"-columns", "place,mag"},
+		{"-proxy", "proxy:3128"},
+		{"-cluster", "-cluster-radius", "0"},
+		{"-region", "japan", "-bbox", "-125,32,-114,42"},
+		{"-days", "7", "-class", "5.0"},
+		{"-days", "7", "-feed", "all_day"},
+		{"-days", "7", "-url", "http://localhost:8000/feed.geojson"},
+		{"-max-depth", "shallow"},
+		{"-min-depth", "300", "-max-depth", "70"},
+		{"-template", "missing.tmpl"},
+		{"-file", "feed.geojson", "-feed", "all_day"},
+		{"-url", "ftp://example.com/feed.geojson"},
+		{"-url", "/tmp/feed.geojson"},
+		{"-url", "http://localhost:8000/feed.geojson", "-feed", "all_day"},
+	}
+
+	for _, args := range tests {
+		if _, err := parseFlags(args); err == nil {
+			t.Errorf("parseFlags(%v) expected an error, got nil", args)
+		}
+	}
+}
+
+func TestParseFlagsTUILimit(t *testing.T) {
+	// -tui takes only its refresh interval from -watch, and limits the list
+	opts, err := parseFlags([]string{"-tui", "-watch", "30s", "-top", "5"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	if opts.limit != 5 {
+		t.Errorf("Expected a limit of 5, got %d", opts.limit)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371.0
+
+// kilometersPerMile is the length of an international mile.
+const kilometersPerMile = 1.609344
+
+// kmToMiles converts kilometers to miles.
+func kmToMiles(km float64) float64 {
+	return km / kilometersPerMile
+}
+
+// haversine returns the great-circle distance in kilometers between two
+// points given in decimal degrees.
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// initialBearing returns the compass bearing in degrees, from 0 (north)
+// clockwise to below 360, at which the great circle from the first point
+// leaves for the second.
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	return math.Mod(bearing+360, 360)
+}
+
+// compassPoints are the eight principal winds, clockwise from north.
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// compassPoint names the principal wind nearest a bearing in degrees.
+func compassPoint(bearing float64) string {
+	sector := int(math.Round(math.Mod(bearing, 360)/45)) % len(compassPoints)
+	if sector < 0 {
+		sector += len(compassPoints)
+	}
+	return compassPoints[sector]
+}
+
+// parseLatLon parses a "lat,lon" pair in decimal degrees.
+func parseLatLon(s string) (lat, lon float64, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid location %q: expected \"lat,lon\"", s)
+	}
+
+	lat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("invalid latitude in %q", s)
+	}
+	lon, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("invalid longitude in %q", s)
+	}
+
+	return lat, lon, nil
+}
+
+// BoundingBox is a rectangular region in decimal degrees. MinLon may be
+// greater than MaxLon for boxes that cross the antimeridian.
+type BoundingBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+// Contains reports whether the point lies inside the box, edges included.
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	// The box wraps around 180°, e.g. 170,-50,-170,-10 around Fiji
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// String formats the box as "minLon,minLat,maxLon,maxLat", the form -bbox
+// accepts.
+func (b BoundingBox) String() string {
+	return fmt.Sprintf("%g,%g,%g,%g", b.MinLon, b.MinLat, b.MaxLon, b.MaxLat)
+}
+
+// regionPresets are the areas -region accepts, approximating each with a
+// box around its seismically active land and coast.
+var regionPresets = map[string]BoundingBox{
+	"alaska":      {MinLon: 172, MinLat: 51, MaxLon: -130, MaxLat: 72},
+	"california":  {MinLon: -124.5, MinLat: 32.5, MaxLon: -114.1, MaxLat: 42},
+	"chile":       {MinLon: -76, MinLat: -56, MaxLon: -66, MaxLat: -17.5},
+	"hawaii":      {MinLon: -160.5, MinLat: 18.5, MaxLon: -154.5, MaxLat: 22.5},
+	"indonesia":   {MinLon: 95, MinLat: -11, MaxLon: 141, MaxLat: 6},
+	"italy":       {MinLon: 6.6, MinLat: 35.5, MaxLon: 18.5, MaxLat: 47.1},
+	"japan":       {MinLon: 122.9, MinLat: 24, MaxLon: 146, MaxLat: 45.6},
+	"mexico":      {MinLon: -118.4, MinLat: 14.5, MaxLon: -86.7, MaxLat: 32.7},
+	"new-zealand": {MinLon: 166, MinLat: -47.5, MaxLon: 179, MaxLat: -34},
+	"turkey":      {MinLon: 25.7, MinLat: 35.8, MaxLon: 44.8, MaxLat: 42.1},
+}
+
+// regionBoundingBox returns the box of a -region preset, or an error listing
+// the presets when the name is unknown.
+func regionBoundingBox(name string) (BoundingBox, error) {
+	box, ok := regionPresets[strings.ToLower(name)]
+	if !ok {
+		return BoundingBox{}, fmt.Errorf("unknown region %q (valid regions: %s)", name, strings.Join(regionNames(), ", "))
+	}
+	return box, nil
+}
+
+// regionNames lists the -region presets alphabetically.
+func regionNames() []string {
+	names := make([]string, 0, len(regionPresets))
+	for preset := range regionPresets {
+		names = append(names, preset)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseBoundingBox parses "minLon,minLat,maxLon,maxLat".
+func parseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("invalid bounding box %q: expected \"minLon,minLat,maxLon,maxLat\"", s)
+	}
+
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("invalid bounding box %q: %q is not a number", s, part)
+		}
+		values[i] = v
+	}
+
+	box := BoundingBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if box.MinLon < -180 || box.MinLon > 180 || box.MaxLon < -180 || box.MaxLon > 180 {
+		return BoundingBox{}, fmt.Errorf("invalid bounding box %q: longitudes must be between -180 and 180", s)
+	}
+	if box.MinLat < -90 || box.MaxLat > 90 || box.MinLat > box.MaxLat {
+		return BoundingBox{}, fmt.Errorf("invalid bounding box %q: latitudes must satisfy -90 <= minLat <= maxLat <= 90", s)
+	}
+
+	return box, nil
+}
+
+// decimalToDMS renders decimal degrees as degrees, minutes and seconds with a
+// hemisphere letter, e.g. 37°46'30"N. isLat selects N/S rather than E/W.
+func decimalToDMS(deg float64, isLat bool) string {
+	hemisphere := "E"
+	if isLat {
+		hemisphere = "N"
+	}
+	if deg < 0 {
+		hemisphere = "W"
+		if isLat {
+			hemisphere = "S"
+		}
+		deg = -deg
+	}
+
+	// Round to whole seconds first so 59.6" carries into the minutes
+	total := int(math.Round(deg * 3600))
+	degrees := total / 3600
+	minutes := total % 3600 / 60
+	seconds := total % 60
+
+	return fmt.Sprintf("%d°%02d'%02d\"%s", degrees, minutes, seconds, hemisphere)
+}
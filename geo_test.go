@@ -0,0 +1,182 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		expected               float64
+	}{
+		{"same point", 35.0, 139.0, 35.0, 139.0, 0},
+		{"quarter meridian", 0, 0, 90, 0, 10007.5},
+		{"San Francisco to Los Angeles", 37.7749, -122.4194, 34.0522, -118.2437, 559.1},
+	}
+
+	for _, tt := range tests {
+		got := haversine(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+		if math.Abs(got-tt.expected) > 0.5 {
+			t.Errorf("%s: expected %.1f km, got %.1f km", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestInitialBearing(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		expected               float64
+		point                  string
+	}{
+		{"due north", 0, 0, 10, 0, 0, "N"},
+		{"due east along the equator", 0, 0, 0, 10, 90, "E"},
+		{"due south", 10, 0, 0, 0, 180, "S"},
+		{"due west across the antimeridian", 0, -179, 0, 179, 270, "W"},
+		{"San Francisco to Los Angeles", 37.7749, -122.4194, 34.0522, -118.2437, 136.5, "SE"},
+	}
+
+	for _, tt := range tests {
+		got := initialBearing(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+		if math.Abs(got-tt.expected) > 0.5 {
+			t.Errorf("%s: expected %.1f°, got %.1f°", tt.name, tt.expected, got)
+		}
+		if point := compassPoint(got); point != tt.point {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.point, point)
+		}
+	}
+}
+
+func TestCompassPoint(t *testing.T) {
+	tests := map[float64]string{0: "N", 22.4: "N", 22.6: "NE", 200: "S", 337.6: "N", 359.9: "N", 360: "N"}
+	for bearing, expected := range tests {
+		if got := compassPoint(bearing); got != expected {
+			t.Errorf("compassPoint(%v): expected %s, got %s", bearing, expected, got)
+		}
+	}
+}
+
+func TestKmToMiles(t *testing.T) {
+	if got := kmToMiles(1.609344); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected 1 mile, got %v", got)
+	}
+	if got := kmToMiles(0); got != 0 {
+		t.Errorf("Expected 0 miles, got %v", got)
+	}
+}
+
+func TestParseLatLon(t *testing.T) {
+	lat, lon, err := parseLatLon("-33.45, -70.66")
+	if err != nil {
+		t.Fatalf("parseLatLon() returned an error: %v", err)
+	}
+	if lat != -33.45 || lon != -70.66 {
+		t.Errorf("Expected -33.45,-70.66, got %v,%v", lat, lon)
+	}
+
+	for _, s := range []string{"", "10", "91,0", "0,181", "a,b", "1,2,3"} {
+		if _, _, err := parseLatLon(s); err == nil {
+			t.Errorf("parseLatLon(%q) expected an error, got nil", s)
+		}
+	}
+}
+
+func TestBoundingBoxContains(t *testing.T) {
+	california := BoundingBox{MinLon: -125, MinLat: 32, MaxLon: -114, MaxLat: 42}
+	// Crosses the antimeridian
+	fiji := BoundingBox{MinLon: 170, MinLat: -25, MaxLon: -170, MaxLat: -10}
+
+	tests := []struct {
+		name     string
+		box      BoundingBox
+		lat, lon float64
+		expected bool
+	}{
+		{"inside", california, 36.0, -120.0, true},
+		{"on edge", california, 32.0, -125.0, true},
+		{"outside longitude", california, 36.0, -110.0, false},
+		{"outside latitude", california, 45.0, -120.0, false},
+		{"wrap east of 180", fiji, -18.0, 178.0, true},
+		{"wrap west of 180", fiji, -18.0, -175.0, true},
+		{"wrap outside", fiji, -18.0, 0.0, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.box.Contains(tt.lat, tt.lon); got != tt.expected {
+			t.Errorf("%s: Contains(%v, %v) = %v, expected %v", tt.name, tt.lat, tt.lon, got, tt.expected)
+		}
+	}
+}
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := parseBoundingBox("-125, 32, -114, 42")
+	if err != nil {
+		t.Fatalf("parseBoundingBox() returned an error: %v", err)
+	}
+	expected := BoundingBox{MinLon: -125, MinLat: 32, MaxLon: -114, MaxLat: 42}
+	if box != expected {
+		t.Errorf("Expected %+v, got %+v", expected, box)
+	}
+
+	for _, s := range []string{"", "1,2,3", "a,2,3,4", "-200,0,10,10", "0,50,10,40"} {
+		if _, err := parseBoundingBox(s); err == nil {
+			t.Errorf("parseBoundingBox(%q) expected an error, got nil", s)
+		}
+	}
+}
+
+func TestRegionBoundingBox(t *testing.T) {
+	tests := []struct {
+		region   string
+		lat, lon float64
+	}{
+		{"california", 34.05, -118.24}, // Los Angeles
+		{"Japan", 35.68, 139.69},       // Tokyo
+		{"alaska", 51.9, 178.5},        // Aleutians, west of the antimeridian
+		{"chile", -33.45, -70.67},      // Santiago
+	}
+
+	for _, test := range tests {
+		box, err := regionBoundingBox(test.region)
+		if err != nil {
+			t.Fatalf("regionBoundingBox(%q) returned an error: %v", test.region, err)
+		}
+		if !box.Contains(test.lat, test.lon) {
+			t.Errorf("Expected %q (%s) to contain %v,%v", test.region, box, test.lat, test.lon)
+		}
+	}
+
+	for name, box := range regionPresets {
+		if parsed, err := parseBoundingBox(box.String()); err != nil || parsed != box {
+			t.Errorf("Preset %q does not round-trip through -bbox: %v", name, err)
+		}
+	}
+
+	if _, err := regionBoundingBox("atlantis"); err == nil || !strings.Contains(err.Error(), "california, chile") {
+		t.Errorf("Expected an error listing the presets, got %v", err)
+	}
+}
+
+func TestDecimalToDMS(t *testing.T) {
+	tests := []struct {
+		deg      float64
+		isLat    bool
+		expected string
+	}{
+		{37.775, true, `37°46'30"N`},
+		{-33.45, true, `33°27'00"S`},
+		{-122.4194, false, `122°25'10"W`},
+		{139.6917, false, `139°41'30"E`},
+		{0, true, `0°00'00"N`},
+		{10.99999, true, `11°00'00"N`},
+	}
+
+	for _, tt := range tests {
+		if got := decimalToDMS(tt.deg, tt.isLat); got != tt.expected {
+			t.Errorf("decimalToDMS(%v, %v) = %s, expected %s", tt.deg, tt.isLat, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+// geohashAlphabet is the base32 alphabet of geohashes, which omits a, i, l
+// and o.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Geohash precisions accepted by -geohash-precision. Six characters locate
+// a point within about 1.2 km by 0.6 km.
+const (
+	defaultGeohashPrecision = 6
+	maxGeohashPrecision     = 12
+)
+
+// geohash encodes a point as a geohash of precision characters. Bits
+// alternate between longitude and latitude, starting with longitude, each
+// halving the remaining range.
+func geohash(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	hash := make([]byte, 0, precision)
+	even := true
+	bit, ch := 0, 0
+	for len(hash) < precision {
+		r, v := &latRange, lat
+		if even {
+			r, v = &lonRange, lon
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+
+		if bit++; bit == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		lat, lon  float64
+		precision int
+		expected  string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"}, // Jutland, the canonical example
+		{37.7749, -122.4194, 6, "9q8yyk"},       // San Francisco
+		{42.605, -5.603, 5, "ezs42"},            // León, from the original description
+		{0, 0, 1, "s"},
+	}
+
+	for _, test := range tests {
+		if got := geohash(test.lat, test.lon, test.precision); got != test.expected {
+			t.Errorf("geohash(%v, %v, %d): expected %q, got %q", test.lat, test.lon, test.precision, test.expected, got)
+		}
+	}
+}
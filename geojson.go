@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// The GeoJSON types of a USGS feed; anything else is not a feed eqk can read.
+const (
+	geoJSONCollection = "FeatureCollection"
+	geoJSONPoint      = "Point"
+)
+
+// keepFeatureJSON makes decoded features keep their original JSON, which
+// only -format geojson reads; main turns it off for the other formats so a
+// feed is not held twice.
+var keepFeatureJSON = true
+
+// UnmarshalJSON decodes a feature and, with keepFeatureJSON, keeps its
+// original JSON so -format geojson can re-emit properties this program does
+// not model.
+func (f *Feature) UnmarshalJSON(data []byte) error {
+	type plainFeature Feature
+	if err := json.Unmarshal(data, (*plainFeature)(f)); err != nil {
+		return err
+	}
+	if keepFeatureJSON {
+		f.raw = append(json.RawMessage(nil), data...)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a geometry, reading the coordinates only for a point
+// so another shape is left for checkFeature to reject rather than failing to
+// decode or being misread as [longitude, latitude, depth].
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &geometry); err != nil {
+		return err
+	}
+
+	g.Type, g.Coordinates = geometry.Type, nil
+	if g.Type != geoJSONPoint || geometry.Coordinates == nil {
+		return nil
+	}
+	return json.Unmarshal(geometry.Coordinates, &g.Coordinates)
+}
+
+// checkCollection reports a document that is not a GeoJSON FeatureCollection.
+func checkCollection(earthquakeData Earthquake) error {
+	if earthquakeData.Type != geoJSONCollection {
+		return fmt.Errorf("unexpected GeoJSON type %q, expected %s", earthquakeData.Type, geoJSONCollection)
+	}
+	return nil
+}
+
+// checkFeature reports a feature whose geometry is not a point. A feature
+// without any geometry has no coordinates to misread and passes.
+func checkFeature(feature Feature) error {
+	geometry := feature.Geometry
+	if geometry.Type == geoJSONPoint || geometry.Type == "" && geometry.Coordinates == nil {
+		return nil
+	}
+
+	kind := geometry.Type
+	if kind == "" {
+		kind = "untyped"
+	}
+	return fmt.Errorf("earthquake %q has %s geometry, expected %s", feature.ID, kind, geoJSONPoint)
+}
+
+// acceptFeature reports whether a feature passes checkFeature. A feature that
+// does not is an error with -strict, and is otherwise skipped with a warning.
+func acceptFeature(feature Feature, strict bool) (bool, error) {
+	err := checkFeature(feature)
+	switch {
+	case err == nil:
+		return true, nil
+	case strict:
+		return false, err
+	}
+	slog.Warn("Skipping earthquake with unexpected geometry", "err", err)
+	return false, nil
+}
+
+// validateEarthquakeData checks the decoded feed with checkCollection and
+// acceptFeature, returning it without the skipped features.
+func validateEarthquakeData(earthquakeData Earthquake, strict bool) (Earthquake, error) {
+	if err := checkCollection(earthquakeData); err != nil {
+		return Earthquake{}, err
+	}
+
+	features := make([]Feature, 0, len(earthquakeData.Features))
+	for _, feature := range earthquakeData.Features {
+		ok, err := acceptFeature(feature, strict)
+		if err != nil {
+			return Earthquake{}, err
+		}
+		if ok {
+			features = append(features, feature)
+		}
+	}
+	earthquakeData.Features = features
+	return earthquakeData, nil
+}
+
+// featureCollection is the GeoJSON document written by -format geojson.
+type featureCollection struct {
+	Type     string            `json:"type"`
+	Features []json.RawMessage `json:"features"`
+}
+
+// writeGeoJSON re-emits the matched features as a GeoJSON FeatureCollection.
+func writeGeoJSON(w io.Writer, quakes []QuakeRecord) error {
+	collection := featureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]json.RawMessage, 0, len(quakes)),
+	}
+
+	for _, quake := range quakes {
+		feature := quake.feature.raw
+		if feature == nil {
+			// Built in code rather than decoded; encode what we know
+			encoded, err := json.Marshal(quake.feature)
+			if err != nil {
+				return err
+			}
+			feature = encoded
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
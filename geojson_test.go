@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteGeoJSON(t *testing.T) {
+	var earthquakeData Earthquake
+	err := json.Unmarshal([]byte(`{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "id": "a", "properties": {"mag": 6.5, "place": "Location 1", "cdi": 4.1},
+			 "geometry": {"type": "Point", "coordinates": [142.1, 38.3, 10]}},
+			{"type": "Feature", "id": "b", "properties": {"mag": 2.0, "place": "Location 2"},
+			 "geometry": {"type": "Point", "coordinates": [0, 0, 5]}}
+		]
+	}`), &earthquakeData)
+	if err != nil {
+		t.Fatalf("Failed to decode features: %v", err)
+	}
+
+	quakes := collectQuakes(earthquakeData, options{minimumMagnitude: 5, maximumMagnitude: 10})
+
+	var buf bytes.Buffer
+	if err := writeGeoJSON(&buf, quakes); err != nil {
+		t.Fatalf("writeGeoJSON() returned an error: %v", err)
+	}
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type       string                 `json:"type"`
+			ID         string                 `json:"id"`
+			Properties map[string]interface{} `json:"properties"`
+			Geometry   Geometry               `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatalf("writeGeoJSON() produced invalid JSON: %v", err)
+	}
+
+	if collection.Type != "FeatureCollection" {
+		t.Errorf("Expected a FeatureCollection, got %q", collection.Type)
+	}
+	if len(collection.Features) != 1 || collection.Features[0].ID != "a" {
+		t.Fatalf("Expected only feature a, got %+v", collection.Features)
+	}
+	feature := collection.Features[0]
+	if feature.Properties["cdi"] != 4.1 {
+		t.Errorf("Expected unmodeled properties to be preserved, got %v", feature.Properties)
+	}
+	if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) != 3 {
+		t.Errorf("Expected geometry to be preserved, got %+v", feature.Geometry)
+	}
+}
+
+// mixedGeometryFeed has a point, a polygon and a feature without geometry.
+const mixedGeometryFeed = `{
+	"type": "FeatureCollection",
+	"features": [
+		{"id": "point", "properties": {"mag": 4.5}, "geometry": {"type": "Point", "coordinates": [142.1, 38.3, 10]}},
+		{"id": "polygon", "properties": {"mag": 5.0}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}},
+		{"id": "missing", "properties": {"mag": 3.0}}
+	]
+}`
+
+func TestDecodeGeometry(t *testing.T) {
+	earthquakeData, err := decodeEarthquakeData(strings.NewReader(mixedGeometryFeed))
+	if err != nil {
+		t.Fatalf("Failed to decode features: %v", err)
+	}
+
+	point := earthquakeData.Features[0].Geometry
+	if point.Type != "Point" || len(point.Coordinates) != 3 || point.Coordinates[1] != 38.3 {
+		t.Errorf("Expected the point's coordinates, got %+v", point)
+	}
+	if polygon := earthquakeData.Features[1].Geometry; polygon.Type != "Polygon" || polygon.Coordinates != nil {
+		t.Errorf("Expected a polygon without coordinates, got %+v", polygon)
+	}
+}
+
+func TestValidateEarthquakeData(t *testing.T) {
+	earthquakeData, err := decodeEarthquakeData(strings.NewReader(mixedGeometryFeed))
+	if err != nil {
+		t.Fatalf("Failed to decode features: %v", err)
+	}
+
+	valid, err := validateEarthquakeData(earthquakeData, false)
+	if err != nil {
+		t.Fatalf("validateEarthquakeData() returned an error: %v", err)
+	}
+	if len(valid.Features) != 2 || valid.Features[0].ID != "point" || valid.Features[1].ID != "missing" {
+		t.Errorf("Expected the polygon to be skipped, got %+v", valid.Features)
+	}
+
+	_, err = validateEarthquakeData(earthquakeData, true)
+	if err == nil || !strings.Contains(err.Error(), `earthquake "polygon" has Polygon geometry`) {
+		t.Errorf("Expected a strict error naming the polygon, got %v", err)
+	}
+
+	earthquakeData.Type = "Feature"
+	if _, err := validateEarthquakeData(earthquakeData, false); err == nil {
+		t.Error("Expected an error for a document that is not a FeatureCollection")
+	}
+}
+
+func TestCheckFeatureUntyped(t *testing.T) {
+	feature := Feature{ID: "a", Geometry: Geometry{Coordinates: []float64{1, 2}}}
+	if err := checkFeature(feature); err == nil || !strings.Contains(err.Error(), "untyped geometry") {
+		t.Errorf("Expected coordinates without a type to be rejected, got %v", err)
+	}
+}
+
+func TestFeatureKeepsJSONOnlyForGeoJSON(t *testing.T) {
+	originalKeep := keepFeatureJSON
+	defer func() { keepFeatureJSON = originalKeep }()
+
+	const data = `{"type": "Feature", "id": "a", "properties": {"mag": 6.5, "cdi": 4.1}}`
+	for _, keep := range []bool{true, false} {
+		keepFeatureJSON = keep
+		var feature Feature
+		if err := json.Unmarshal([]byte(data), &feature); err != nil {
+			t.Fatalf("Failed to decode feature: %v", err)
+		}
+		if feature.ID != "a" || (feature.raw != nil) != keep {
+			t.Errorf("keepFeatureJSON %v: got ID %q and raw %q", keep, feature.ID, feature.raw)
+		}
+	}
+}
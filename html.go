@@ -0,0 +1,78 @@
+package main
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlReport renders the self-contained HTML page for -format html. Clicking
+// a column header sorts the table by that column.
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{"plural": plural}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Earthquake report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; cursor: pointer; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Earthquake report</h1>
+<p>{{plural (len .) "earthquake"}}. Data source: <a href="https://earthquake.usgs.gov/">USGS</a>.</p>
+<table id="quakes">
+<thead>
+<tr><th data-type="text">Place</th><th data-type="num">Magnitude</th><th data-type="num">Depth (km)</th><th data-type="text">Time (UTC)</th></tr>
+</thead>
+<tbody>
+{{- range .}}
+<tr>
+<td>{{if .URL}}<a href="{{.URL}}">{{.Place}}</a>{{else}}{{.Place}}{{end}}</td>
+<td class="num">{{.MagField}}</td>
+<td class="num">{{.DepthField}}</td>
+<td>{{.TimeField}}</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.querySelectorAll("#quakes th").forEach(function (th, column) {
+  var ascending = false;
+  th.addEventListener("click", function () {
+    var tbody = document.querySelector("#quakes tbody");
+    var rows = Array.from(tbody.rows);
+    var numeric = th.dataset.type === "num";
+    ascending = !ascending;
+    rows.sort(function (a, b) {
+      var x = a.cells[column].textContent, y = b.cells[column].textContent;
+      var order = numeric ? (parseFloat(x) || 0) - (parseFloat(y) || 0) : x.localeCompare(y);
+      return ascending ? order : -order;
+    });
+    rows.forEach(function (row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// htmlRow exposes the formatted fields a report row needs.
+type htmlRow struct {
+	QuakeRecord
+	MagField   string
+	DepthField string
+	TimeField  string
+}
+
+// writeHTML writes the earthquakes as a self-contained HTML page. Every
+// dynamic field is escaped by html/template.
+func writeHTML(w io.Writer, quakes []QuakeRecord) error {
+	rows := make([]htmlRow, 0, len(quakes))
+	for _, quake := range quakes {
+		rows = append(rows, htmlRow{QuakeRecord: quake, MagField: quake.magField(), DepthField: quake.depthField(), TimeField: quake.timeField()})
+	}
+	return htmlReport.Execute(w, rows)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTMLEscapesPlace(t *testing.T) {
+	quakes := []QuakeRecord{{
+		Place: `<script>alert("x")</script>`,
+		Mag:   5.5,
+		URL:   "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+	}}
+
+	var buf bytes.Buffer
+	if err := writeHTML(&buf, quakes); err != nil {
+		t.Fatalf("writeHTML() returned an error: %v", err)
+	}
+
+	page := buf.String()
+	if strings.Contains(page, `<script>alert`) {
+		t.Error("Expected place name to be escaped")
+	}
+	if !strings.Contains(page, `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;`) {
+		t.Errorf("Escaped place name not found in page:\n%s", page)
+	}
+	if !strings.Contains(page, `<a href="https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd">`) {
+		t.Error("Expected a link to the USGS event page")
+	}
+}
+
+func TestWriteHTMLCount(t *testing.T) {
+	tests := []struct {
+		quakes   []QuakeRecord
+		expected string
+	}{
+		{nil, "<p>0 earthquakes."},
+		{[]QuakeRecord{{Place: "A"}}, "<p>1 earthquake."},
+		{[]QuakeRecord{{Place: "A"}, {Place: "B"}}, "<p>2 earthquakes."},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeHTML(&buf, tt.quakes); err != nil {
+			t.Fatalf("writeHTML() returned an error: %v", err)
+		}
+		if !strings.Contains(buf.String(), tt.expected) {
+			t.Errorf("Expected %q in page:\n%s", tt.expected, buf.String())
+		}
+	}
+}
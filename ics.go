@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsTimeFormat is the iCalendar UTC date-time form, e.g. 20240102T150405Z.
+const icsTimeFormat = "20060102T150405Z"
+
+// icsMaxLineLength is the longest content line RFC 5545 allows, in octets,
+// before it must be folded.
+const icsMaxLineLength = 75
+
+// icsEscaper escapes TEXT values as RFC 5545 requires.
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// writeICS writes an iCalendar file with one VEVENT per earthquake, starting
+// at its origin time.
+func writeICS(w io.Writer, quakes []QuakeRecord) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeICSLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//eqk//Earthquakes//EN")
+	line("CALSCALE", "GREGORIAN")
+
+	stamp := icsTimestamp(timeNow())
+	for _, quake := range quakes {
+		summary := "Earthquake near " + quake.Place
+		if !quake.unknownMag {
+			summary = fmt.Sprintf("M%.1f near %s", quake.Mag, quake.Place)
+		}
+
+		var description []string
+		if quake.hasDepth {
+			description = append(description, fmt.Sprintf("Depth: %.1f km", quake.Depth))
+		}
+		if quake.URL != "" {
+			description = append(description, "More info: "+quake.URL)
+		}
+
+		line("BEGIN", "VEVENT")
+		uid := quake.ID
+		if uid == "" {
+			// Features without an ID still need a stable UID
+			uid = icsTimestamp(quake.Time)
+		}
+		line("UID", uid+"@earthquake.usgs.gov")
+		line("DTSTAMP", stamp)
+		line("DTSTART", icsTimestamp(quake.Time))
+		line("SUMMARY", icsEscaper.Replace(summary))
+		if len(description) > 0 {
+			line("DESCRIPTION", icsEscaper.Replace(strings.Join(description, "\n")))
+		}
+		if quake.hasLocation {
+			line("GEO", fmt.Sprintf("%s;%s", formatFloat(quake.Latitude), formatFloat(quake.Longitude)))
+		}
+		if quake.URL != "" {
+			line("URL", quake.URL)
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// writeICSLine writes a content line ending in CRLF, folding it into lines of
+// at most icsMaxLineLength octets, each continuation starting with a space.
+// Folds never split a UTF-8 sequence.
+func writeICSLine(w *bufio.Writer, s string) {
+	limit := icsMaxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts towards the next line's length
+		limit = icsMaxLineLength - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+// icsTimestamp formats t in UTC as an iCalendar date-time, whatever its zone.
+func icsTimestamp(t time.Time) string {
+	return t.UTC().Format(icsTimeFormat)
+}
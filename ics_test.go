@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteICS(t *testing.T) {
+	originalNow := timeNow
+	defer func() { timeNow = originalNow }()
+	timeNow = func() time.Time { return time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC) }
+
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+	quake := QuakeRecord{
+		ID:    "us1000abcd",
+		Place: "10 km S of Somewhere, Chile",
+		Mag:   5.2,
+		Time:  time.Date(2024, 1, 2, 12, 4, 5, 0, saoPaulo),
+		URL:   "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+	}
+	quake.setCoordinates([]float64{-70.66, -33.45, 10})
+
+	var buf bytes.Buffer
+	if err := writeICS(&buf, []QuakeRecord{quake}); err != nil {
+		t.Fatalf("writeICS() returned an error: %v", err)
+	}
+
+	expected := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//eqk//Earthquakes//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:us1000abcd@earthquake.usgs.gov\r\n" +
+		"DTSTAMP:20240103T000000Z\r\n" +
+		"DTSTART:20240102T150405Z\r\n" +
+		"SUMMARY:M5.2 near 10 km S of Somewhere\\, Chile\r\n" +
+		"DESCRIPTION:Depth: 10.0 km\\nMore info: https://earthquake.usgs.gov/earthqua\r\n" +
+		" kes/eventpage/us1000abcd\r\n" +
+		"GEO:-33.45;-70.66\r\n" +
+		"URL:https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected iCalendar output:\n%q\nexpected:\n%q", buf.String(), expected)
+	}
+}
+
+func TestWriteICSLineFolding(t *testing.T) {
+	var buf bytes.Buffer
+	quake := QuakeRecord{ID: "x", Place: strings.Repeat("é", 100), unknownMag: true}
+	if err := writeICS(&buf, []QuakeRecord{quake}); err != nil {
+		t.Fatalf("writeICS() returned an error: %v", err)
+	}
+
+	var unfolded strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > icsMaxLineLength {
+			t.Errorf("Line %d is %d octets, longer than %d: %q", i, len(line), icsMaxLineLength, line)
+		}
+		if !strings.HasPrefix(line, " ") {
+			unfolded.WriteString("\n")
+		}
+		unfolded.WriteString(strings.TrimPrefix(line, " "))
+	}
+	if !strings.Contains(unfolded.String(), "\nSUMMARY:Earthquake near "+strings.Repeat("é", 100)+"\n") {
+		t.Errorf("Expected the folded summary to unfold intact, got:\n%s", unfolded.String())
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+)
+
+// kmlIcon is the placemark icon shipped with Google Earth.
+const kmlIcon = "http://maps.google.com/mapfiles/kml/shapes/earthquake.png"
+
+// Minimal KML schema used by -format kml.
+type kmlDocument struct {
+	XMLName  xml.Name `xml:"kml"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Document struct {
+		Name       string         `xml:"name"`
+		Styles     []kmlStyle     `xml:"Style"`
+		Placemarks []kmlPlacemark `xml:"Placemark"`
+	} `xml:"Document"`
+}
+
+type kmlStyle struct {
+	ID        string  `xml:"id,attr"`
+	IconScale float64 `xml:"IconStyle>scale"`
+	IconHref  string  `xml:"IconStyle>Icon>href"`
+}
+
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	StyleURL    string `xml:"styleUrl"`
+	Coordinates string `xml:"Point>coordinates"`
+}
+
+// kmlStyleID names the style for a whole magnitude, clamped to 0..9.
+func kmlStyleID(mag float64) string {
+	return fmt.Sprintf("mag%d", int(math.Max(0, math.Min(9, math.Floor(mag)))))
+}
+
+// writeKML writes one placemark per earthquake. Icons grow with magnitude so
+// large earthquakes stand out.
+func writeKML(w io.Writer, quakes []QuakeRecord) error {
+	doc := kmlDocument{XMLNS: "http://www.opengis.net/kml/2.2"}
+	doc.Document.Name = "Earthquakes"
+
+	for m := 0; m <= 9; m++ {
+		doc.Document.Styles = append(doc.Document.Styles, kmlStyle{
+			ID:        kmlStyleID(float64(m)),
+			IconScale: 0.5 + 0.25*float64(m),
+			IconHref:  kmlIcon,
+		})
+	}
+
+	for _, quake := range quakes {
+		if !quake.hasLocation {
+			continue
+		}
+
+		magnitude, name := "unknown", "M? "+quake.Place
+		if !quake.unknownMag {
+			magnitude, name = quake.magField(), fmt.Sprintf("M%.1f %s", quake.Mag, quake.Place)
+		}
+
+		description := fmt.Sprintf("Magnitude: %s\nTime: %s", magnitude, quake.timeField())
+		if quake.hasDepth {
+			description = fmt.Sprintf("Magnitude: %s\nDepth: %s km\nTime: %s", magnitude, quake.depthField(), quake.timeField())
+		}
+		if quake.URL != "" {
+			description += "\n" + quake.URL
+		}
+
+		longitude, latitude := quake.coordinateFields()
+		doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+			Name:        name,
+			Description: description,
+			StyleURL:    "#" + kmlStyleID(quake.Mag),
+			Coordinates: longitude + "," + latitude,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestWriteKML(t *testing.T) {
+	quakes := []QuakeRecord{
+		{Place: "Coast & Sea", Mag: 7.3},
+		{Place: "No location", Mag: 4.0},
+	}
+	quakes[0].setCoordinates([]float64{142.1, 38.3, 10})
+
+	var buf bytes.Buffer
+	if err := writeKML(&buf, quakes); err != nil {
+		t.Fatalf("writeKML() returned an error: %v", err)
+	}
+
+	var doc kmlDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("writeKML() produced invalid XML: %v", err)
+	}
+
+	placemarks := doc.Document.Placemarks
+	if len(placemarks) != 1 {
+		t.Fatalf("Expected 1 placemark, got %d", len(placemarks))
+	}
+	if placemarks[0].Name != "M7.3 Coast & Sea" {
+		t.Errorf("Unexpected placemark name %q", placemarks[0].Name)
+	}
+	if placemarks[0].Coordinates != "142.1,38.3" {
+		t.Errorf("Expected lon,lat coordinates, got %q", placemarks[0].Coordinates)
+	}
+	if placemarks[0].StyleURL != "#mag7" {
+		t.Errorf("Expected style #mag7, got %q", placemarks[0].StyleURL)
+	}
+}
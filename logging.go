@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Diagnostic formats accepted by the -log-format flag.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// setupLogging configures the default slog logger. Text keeps the familiar
+// log package lines on stderr; JSON writes one object per record to w for
+// log collectors. verbose adds debug records for fetches and cache hits.
+func setupLogging(format string, verbose bool, w io.Writer) {
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+
+	if format == logFormatJSON {
+		slog.SetDefault(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+		return
+	}
+	slog.SetLogLoggerLevel(level)
+}
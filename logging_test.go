@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestSetupLoggingJSON(t *testing.T) {
+	original := slog.Default()
+	defer slog.SetDefault(original)
+
+	var buf bytes.Buffer
+	setupLogging(logFormatJSON, false, &buf)
+
+	slog.Debug("Hidden without -verbose")
+	slog.Warn("Fetch failed, retrying", "feed", "https://example.com/feed.geojson", "attempt", 1)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["level"] != "WARN" || record["msg"] != "Fetch failed, retrying" || record["feed"] != "https://example.com/feed.geojson" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+
+	buf.Reset()
+	setupLogging(logFormatJSON, true, &buf)
+	slog.Debug("Fetching feed")
+	if buf.Len() == 0 {
+		t.Error("Expected debug records with -verbose")
+	}
+}
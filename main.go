@@ -6,17 +6,43 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-// EarthquakeAPIURL is the URL for earthquake data.
-var EarthquakeAPIURL = "https://earthquake.usgs.gov/earthquakes/feed/v1.0/summary/significant_month.geojson"
+// version, commit and date identify the build; release builds set them with
+// -ldflags "-X main.version=1.2.3 -X main.commit=abc1234 -X main.date=2024-01-02".
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
+// versionString describes the build for -version.
+func versionString() string {
+	return fmt.Sprintf("eqk %s (commit %s, built %s, %s)", version, commit, date, runtime.Version())
+}
+
+// userAgent identifies eqk to USGS, as they recommend for API clients.
+func userAgent() string {
+	return "eqk/" + version + " (+github.com/mpinheir/eqk)"
+}
 
 // Metadata contains metadata information.
 type Metadata struct {
@@ -30,90 +56,674 @@ type Metadata struct {
 
 // Earthquake represents earthquake data.
 type Earthquake struct {
-	Type     string `json:"type"`
-	Meta     Metadata
-	Features []struct {
-		Type       string `json:"type"`
-		Properties struct {
-			Mag     float64 `json:"mag"`
-			Place   string  `json:"place"`
-			Time    int64   `json:"time"`
-			Updated int64   `json:"updated"`
-			Tz      int     `json:"tz"`
-		} `json:"properties"`
-	} `json:"features"`
-}
-
-// Program will display Earthquakes with magnitude > minimumMagnitude
-var minimumMagnitude float64
+	Type     string    `json:"type"`
+	Meta     Metadata  `json:"metadata"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a single earthquake in the feed.
+type Feature struct {
+	Type       string     `json:"type"`
+	ID         string     `json:"id"`
+	Properties Properties `json:"properties"`
+	Geometry   Geometry   `json:"geometry"`
+
+	// raw is the feature as it appeared in the feed
+	raw json.RawMessage
+}
+
+// Properties holds the USGS event attributes of a feature.
+type Properties struct {
+	Mag     *float64 `json:"mag"`     // null for some events
+	MagType string   `json:"magType"` // how the magnitude was measured: ml, mb, mww...
+	Place   string   `json:"place"`
+	Time    int64    `json:"time"`
+	Updated int64    `json:"updated"`
+	Tz      int      `json:"tz"`
+	Tsunami int      `json:"tsunami"`
+	Alert   string   `json:"alert"`
+	Status  string   `json:"status"` // automatic until a seismologist reviews it
+	Net     string   `json:"net"`    // contributing network: us, ci, nc...
+	Sig     int      `json:"sig"`
+	Felt    *int     `json:"felt"` // null when nobody has reported feeling it
+	URL     string   `json:"url"`
+}
+
+// magnitude returns the event magnitude; ok is false when USGS gave none.
+func (p Properties) magnitude() (mag float64, ok bool) {
+	if p.Mag == nil {
+		return 0, false
+	}
+	return *p.Mag, true
+}
+
+// Geometry is a GeoJSON point as [longitude, latitude, depth].
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// Exit codes, as documented in the usage text.
+const (
+	exitMatches   = 0
+	exitNoMatches = 1
+	exitError     = 2
+)
 
 func main() {
+	os.Exit(runMain(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// runMain is main without the exit: it parses args, runs them and returns
+// the exit code, writing errors to stderr.
+func runMain(args []string, stdout, stderr io.Writer) int {
+	opts, err := parseArgs(args, stderr, true)
+	if err == flag.ErrHelp {
+		return exitMatches
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
 
-	minimumMagnitude = 0
+	setupLogging(opts.logFormat, opts.verbose || opts.debug, stderr)
+	warnTimezone(opts)
+	if opts.debug {
+		debugOutput = stderr
+	}
+	keepFeatureJSON = opts.format == formatGeoJSON
+
+	if opts.completion != "" {
+		fmt.Fprint(stdout, opts.completion)
+		return exitMatches
+	}
+	if opts.showVersion {
+		fmt.Fprintln(stdout, versionString())
+		return exitMatches
+	}
+	if opts.listFields {
+		if err := writeFields(stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitError
+		}
+		return exitMatches
+	}
+
+	matched, err := run(opts, stdout)
+	if err != nil {
+		if opts.logFormat == logFormatJSON {
+			slog.Error("Run failed", "err", err)
+		} else {
+			fmt.Fprintln(stderr, err)
+		}
+		return exitError
+	}
+	if !matched {
+		return exitNoMatches
+	}
+	return exitMatches
+}
+
+// run carries out the parsed command line, writing to stdout unless -o
+// names a file, and leaving exit behavior to runMain.
+// matched reports whether any earthquake matched the filters; watch mode
+// always counts as matched, and -diff matches when anything changed.
+func run(opts options, stdout io.Writer) (matched bool, err error) {
+	// Ctrl-C cancels an in-flight request instead of leaving it dangling
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	transport, err := newTransport(opts)
+	if err != nil {
+		return false, err
+	}
+	if transport != nil {
+		httpClient = &http.Client{Transport: transport}
+	}
+
+	if opts.serveAddr != "" {
+		return true, serveQuakes(ctx, opts)
+	}
+	if opts.tui {
+		return true, browseQuakes(ctx, opts)
+	}
+
+	out := stdout
+	var file *os.File
+	if opts.outputPath != "" {
+		if file, err = os.Create(opts.outputPath); err != nil {
+			return false, fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	opts.colorize = opts.format == formatText && useColor(opts.color, out)
 
-	if len(os.Args[1:]) > 0 {
-		if n, err := strconv.ParseFloat(os.Args[1], 64); err == nil {
-			minimumMagnitude = n
+	count := 0
+	if opts.watch > 0 {
+		if _, err := watchQuakes(ctx, out, opts); err != nil {
+			return false, err
+		}
+		count = 1
+	} else if opts.statePath != "" {
+		if count, err = watchQuakes(ctx, out, opts); err != nil {
+			return false, err
+		}
+	} else if opts.diff {
+		if count, err = reportDiff(ctx, out, opts); err != nil {
+			return false, err
 		}
+	} else if count, err = listQuakes(ctx, out, opts); err != nil {
+		return false, err
 	}
 
-	fmt.Println("Total number of Earthquakes: ", listquakes(minimumMagnitude))
+	if file != nil {
+		if err := file.Close(); err != nil {
+			return false, fmt.Errorf("failed to write output file: %w", err)
+		}
+	}
 
+	return count > 0, nil
 }
 
-func listquakes(minimumMagnitude float64) int {
+// listQuakes prints the earthquakes matching opts in the selected format and
+// returns how many matched. Outputs that need no other earthquake to write
+// one are streamed; the rest decode the whole feed first.
+func listQuakes(ctx context.Context, w io.Writer, opts options) (int, error) {
+	if canStream(opts) {
+		return streamQuakes(ctx, w, opts)
+	}
+
 	// Fetch earthquake data from the API
-	earthquakeData, err := fetchEarthquakeData()
+	earthquakeData, _, err := loadEarthquakeData(ctx, opts)
 	if err != nil {
-		log.Fatal("Failed to fetch earthquake data:", err)
+		return 0, fmt.Errorf("failed to fetch earthquake data: %w", err)
 	}
 
-	fmt.Println("-------------------------------------------------------------------")
-	fmt.Printf("Earthquake(s) above %.1f degrees, in the last 30 day:\n", minimumMagnitude)
-	fmt.Println("-------------------------------------------------------------------")
+	// Collect the matching earthquakes first so every format renders the same set
+	quakes := collectQuakes(earthquakeData, opts)
 
-	totEarthquake := 0
+	if opts.dbPath != "" {
+		if err := saveQuakes(opts.dbPath, quakes); err != nil {
+			return 0, fmt.Errorf("failed to save to database: %w", err)
+		}
+	}
 
-	for _, feature := range earthquakeData.Features {
+	if opts.countOnly {
+		_, err := fmt.Fprintln(w, len(quakes))
+		return len(quakes), err
+	}
 
-		magnitude := feature.Properties.Mag
+	// Only the first opts.limit earthquakes are shown, but all matches are counted
+	shown := quakes
+	if opts.limit > 0 && len(shown) > opts.limit {
+		shown = shown[:opts.limit]
+	}
 
-		if magnitude > minimumMagnitude {
-			fmt.Println("Epicenter =", feature.Properties.Place)
-			fmt.Println("Magnitude:", magnitude)
+	switch opts.format {
+	case formatCSV:
+		err = writeCSV(w, shown, opts.columns)
+	case formatJSON:
+		err = writeJSON(w, shown)
+	case formatJSONL:
+		err = writeJSONL(w, shown)
+	case formatICS:
+		err = writeICS(w, shown)
+	case formatRSS:
+		err = writeRSS(w, shown)
+	case formatTable:
+		err = writeTable(w, shown, opts.columns)
+	case formatMD:
+		err = writeMarkdown(w, shown)
+	case formatHTML:
+		err = writeHTML(w, shown)
+	case formatGeoJSON:
+		err = writeGeoJSON(w, shown)
+	case formatKML:
+		err = writeKML(w, shown)
+	case formatYAML:
+		err = writeYAML(w, shown)
+	case formatTemplate:
+		err = writeTemplate(w, opts.template, shown)
+	default:
+		printQuakes(w, earthquakeData.Meta, shown, len(quakes), opts)
+		printSummary(w, quakes, len(shown), opts)
+		if opts.histogram {
+			printHistogram(w, quakes)
+		}
+		if opts.trend {
+			printTrend(w, quakes, opts)
+		}
+		if opts.asciiMap {
+			printASCIIMap(w, quakes)
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write output: %w", err)
+	}
 
-			t := time.UnixMilli(feature.Properties.Time)
-			fmt.Println("Time:", t.UTC())
+	notifyQuakes(shown, opts)
 
-			fmt.Println("-------------------------------------------------------------------")
+	return len(quakes), nil
+}
 
-			totEarthquake++
+// collectQuakes converts the features that pass the filters in opts into
+// records, sorted as requested. Repeated events are listed once.
+func collectQuakes(earthquakeData Earthquake, opts options) []QuakeRecord {
+	var quakes []QuakeRecord
+
+	for _, feature := range dropRepeats(earthquakeData.Features) {
+		quake := newMatchRecord(feature, opts)
+		if opts.matches(quake) {
+			quakes = append(quakes, quake)
 		}
 	}
 
-	return totEarthquake
+	if opts.sort != "" {
+		sortQuakes(quakes, opts.sort)
+	}
+
+	return quakes
+}
+
+// newMatchRecord flattens a feature for filtering, adding its distance from
+// the -near point.
+func newMatchRecord(feature Feature, opts options) QuakeRecord {
+	quake := newQuakeRecord(feature)
+	if opts.near && quake.hasLocation {
+		distance := haversine(opts.nearLatitude, opts.nearLongitude, quake.Latitude, quake.Longitude)
+		quake.Distance = &distance
+	}
+	return quake
+}
+
+// debugOutput receives the raw body of every fetched feed when -debug is
+// given; nil disables the dump.
+var debugOutput io.Writer
+
+// dumpResponse writes a feed body to debugOutput, framed so it stands out
+// from the log records around it.
+func dumpResponse(url string, body []byte) {
+	if debugOutput == nil {
+		return
+	}
+	fmt.Fprintf(debugOutput, "----- %s (%d bytes)\n", url, len(body))
+	debugOutput.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		fmt.Fprintln(debugOutput)
+	}
+	fmt.Fprintln(debugOutput, "-----")
+}
+
+// httpClient sends every feed request; tests replace it to stub the network.
+// The per-request timeout is applied to a copy.
+var httpClient = &http.Client{}
+
+// feedResponse is the outcome of a successful feed request.
+type feedResponse struct {
+	body []byte
+	etag string
+
+	// notModified is set when USGS answered 304 to the ETag we sent; body is
+	// then empty and the cached copy is still current
+	notModified bool
+}
+
+// feedStream is a successful feed request whose body has not been read yet.
+type feedStream struct {
+	// body is the decompressed response body; it is nil when notModified is
+	// set
+	body io.ReadCloser
+	etag string
+
+	notModified bool
+}
+
+// responseBody reads a decompressed response body and closes the response.
+type responseBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (b responseBody) Close() error { return b.closer.Close() }
+
+// fetchFeed downloads the raw feed body from url in a single attempt. A
+// non-empty etag is sent as If-None-Match.
+func fetchFeed(ctx context.Context, url string, timeout time.Duration, etag string) (feedResponse, error) {
+	stream, err := openFeed(ctx, url, timeout, etag)
+	if err != nil || stream.notModified {
+		return feedResponse{etag: stream.etag, notModified: stream.notModified}, err
+	}
+	defer stream.body.Close()
+
+	body, err := io.ReadAll(stream.body)
+	if err != nil {
+		return feedResponse{}, requestError(ctx, err, timeout)
+	}
+	dumpResponse(url, body)
+
+	return feedResponse{body: body, etag: stream.etag}, nil
 }
 
-func fetchEarthquakeData() (Earthquake, error) {
+// openFeed sends the request for fetchFeed and returns the body unread, for
+// callers that decode it as it arrives. The timeout also bounds reading it.
+func openFeed(ctx context.Context, url string, timeout time.Duration, etag string) (feedStream, error) {
 	// Build the request
-	req, err := http.NewRequest("GET", EarthquakeAPIURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return Earthquake{}, err
+		return feedStream{}, err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	// Asking explicitly makes the transport leave the body compressed, so
+	// decodedBody inflates it
+	req.Header.Set("Accept-Encoding", "gzip")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
 	}
 
-	// Create an HTTP client and send the request
-	client := &http.Client{}
+	// Send the request with the shared client, bounded by timeout
+	client := *httpClient
+	client.Timeout = timeout
+	slog.Debug("Fetching feed", "feed", url, "etag", etag)
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
-		return Earthquake{}, err
+		return feedStream{}, requestError(ctx, err, timeout)
+	}
+	slog.Debug("Feed responded", "feed", url, "resolved", resp.Request.URL.String(), "status", resp.StatusCode,
+		"content_length", resp.ContentLength, "content_encoding", resp.Header.Get("Content-Encoding"),
+		"duration", time.Since(start).Round(time.Millisecond))
+
+	if resp.StatusCode != http.StatusOK {
+		// Only a 200 response hands its body to the caller
+		defer resp.Body.Close()
+	}
+	if etag != "" && resp.StatusCode == http.StatusNotModified {
+		return feedStream{etag: etag, notModified: true}, nil
+	}
+
+	// Don't try to decode error pages as GeoJSON
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return feedStream{}, newRateLimitError(resp.Header.Get("Retry-After"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := statusError(resp)
+		if resp.StatusCode >= 500 {
+			return feedStream{}, temporaryError{err}
+		}
+		return feedStream{}, err
 	}
-	defer resp.Body.Close()
 
-	// Decode the JSON response into the Earthquake struct
+	decoded, err := decodedBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return feedStream{}, temporaryError{err}
+	}
+	return feedStream{body: responseBody{decoded, resp.Body}, etag: resp.Header.Get("ETag")}, nil
+}
+
+// requestError classifies a failed request: cancellation is returned as is,
+// anything else is a temporary error worth retrying. Timeouts and proxy
+// failures are described plainly.
+func requestError(ctx context.Context, err error, timeout time.Duration) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return temporaryError{fmt.Errorf("request timed out after %s", timeout)}
+	}
+	// Name the proxy rather than USGS when it is the proxy that is down
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return temporaryError{fmt.Errorf("failed to connect to proxy: %w", opErr.Err)}
+	}
+	return temporaryError{err}
+}
+
+// decodeEarthquakeData decodes a GeoJSON feed into the Earthquake struct.
+func decodeEarthquakeData(r io.Reader) (Earthquake, error) {
 	var earthquakeData Earthquake
-	if err := json.NewDecoder(resp.Body).Decode(&earthquakeData); err != nil {
+	if err := json.NewDecoder(r).Decode(&earthquakeData); err != nil {
 		return Earthquake{}, err
 	}
 
 	return earthquakeData, nil
 }
+
+// loadEarthquakeData reads the feed from the -file path (or stdin) when given,
+// and fetches it from USGS otherwise, then checks its structure with
+// validateEarthquakeData. cached reports that the data was served from the
+// disk cache, so it has not changed since the previous fetch.
+func loadEarthquakeData(ctx context.Context, opts options) (Earthquake, bool, error) {
+	earthquakeData, cached, err := readEarthquakeData(ctx, opts)
+	if err != nil {
+		return Earthquake{}, false, err
+	}
+	earthquakeData, err = validateEarthquakeData(earthquakeData, opts.strict)
+	return earthquakeData, cached, err
+}
+
+// readEarthquakeData decodes the feed for loadEarthquakeData.
+func readEarthquakeData(ctx context.Context, opts options) (Earthquake, bool, error) {
+	switch opts.inputPath {
+	case "":
+		if len(opts.feedURLs) > 1 {
+			return fetchFeeds(ctx, opts.feedURLs, opts)
+		}
+		body, cached, err := fetchCached(ctx, opts.url, opts)
+		if err != nil {
+			return Earthquake{}, false, err
+		}
+		earthquakeData, err := decodeEarthquakeData(bytes.NewReader(body))
+		return earthquakeData, cached, err
+	case stdinPath:
+		earthquakeData, err := decodeEarthquakeData(os.Stdin)
+		return earthquakeData, false, err
+	}
+
+	file, err := os.Open(opts.inputPath)
+	if err != nil {
+		return Earthquake{}, false, err
+	}
+	defer file.Close()
+
+	earthquakeData, err := decodeEarthquakeData(file)
+	return earthquakeData, false, err
+}
+
+// maxConcurrentFetches bounds how many feeds are downloaded at once.
+const maxConcurrentFetches = 4
+
+// fetchFeeds downloads several feeds in parallel and merges their features,
+// dropping events that appear in more than one. A failing feed is reported and
+// skipped as long as another feed succeeds; only when all fail is an error,
+// listing every failure, returned. cached reports that every feed was served
+// from the disk cache.
+func fetchFeeds(ctx context.Context, urls []string, opts options) (Earthquake, bool, error) {
+	type result struct {
+		data   Earthquake
+		cached bool
+		err    error
+	}
+	results := make([]result, len(urls))
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, maxConcurrentFetches)
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			body, cached, err := fetchCached(ctx, url, opts)
+			if err == nil {
+				results[i].data, err = decodeEarthquakeData(bytes.NewReader(body))
+			}
+			results[i].cached, results[i].err = cached, err
+		}(i, url)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return Earthquake{}, false, ctx.Err()
+	}
+
+	var merged Earthquake
+	var failures []string
+	cached := true
+	for i, r := range results {
+		if r.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", urls[i], r.err))
+			continue
+		}
+		merged.Type = r.data.Type
+		if r.data.Meta.Generated > merged.Meta.Generated {
+			merged.Meta.Generated = r.data.Meta.Generated
+		}
+		merged.Features = append(merged.Features, r.data.Features...)
+		cached = cached && r.cached
+	}
+
+	if len(failures) == len(urls) {
+		return Earthquake{}, false, fmt.Errorf("all feeds failed:\n  %s", strings.Join(failures, "\n  "))
+	}
+	for i, r := range results {
+		if r.err != nil {
+			slog.Warn("Skipping feed", "feed", urls[i], "err", r.err)
+		}
+	}
+
+	merged.Features = dedupFeatures(merged.Features)
+	merged.Meta.Count = len(merged.Features)
+	return merged, cached, nil
+}
+
+// errorSnippetLength caps how much of an error response body is quoted.
+const errorSnippetLength = 200
+
+// decodedBody returns the response body, decompressed when the server
+// gzipped it and as is otherwise.
+func decodedBody(resp *http.Response) (io.Reader, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
+	}
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip response: %w", err)
+	}
+	return reader, nil
+}
+
+// statusError describes a non-200 response, quoting the start of its body.
+func statusError(resp *http.Response) error {
+	var body []byte
+	if decoded, err := decodedBody(resp); err == nil {
+		body, _ = io.ReadAll(io.LimitReader(decoded, errorSnippetLength))
+	}
+	snippet := strings.TrimSpace(string(body))
+	if snippet == "" {
+		return fmt.Errorf("unexpected status %d from USGS", resp.StatusCode)
+	}
+	return fmt.Errorf("unexpected status %d from USGS: %s", resp.StatusCode, snippet)
+}
+
+// rateLimitError is a 429 Too Many Requests response; retryAfter is how
+// long USGS asked to wait, zero when it did not say.
+type rateLimitError struct {
+	retryAfter time.Duration
+}
+
+func (e rateLimitError) Error() string {
+	if e.retryAfter > 0 {
+		return fmt.Sprintf("rate limited by USGS (status 429); try again in %s", e.retryAfter)
+	}
+	return "rate limited by USGS (status 429); try again later"
+}
+
+// newRateLimitError reads a Retry-After header, given either in seconds or
+// as an HTTP date.
+func newRateLimitError(retryAfter string) rateLimitError {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && seconds > 0 {
+		return rateLimitError{retryAfter: time.Duration(seconds) * time.Second}
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		if wait := date.Sub(timeNow()).Round(time.Second); wait > 0 {
+			return rateLimitError{retryAfter: wait}
+		}
+	}
+	return rateLimitError{}
+}
+
+// temporaryError marks fetch failures worth retrying: network errors and 5xx responses.
+type temporaryError struct {
+	err error
+}
+
+func (e temporaryError) Error() string { return e.err.Error() }
+
+func (e temporaryError) Unwrap() error { return e.err }
+
+// retryBaseDelay is the wait before the first retry; it doubles on each attempt.
+var retryBaseDelay = time.Second
+
+// fetchWithRetry calls fetchFeed, retrying temporary failures up to retries
+// times with exponential backoff. A rate limit is returned at once unless
+// waitRateLimit is set, as in watch mode, when the retry waits as long as
+// USGS asked, or the backoff delay if it did not say.
+func fetchWithRetry(ctx context.Context, url string, timeout time.Duration, retries int, etag string, waitRateLimit bool) (feedResponse, error) {
+	var resp feedResponse
+	err := retryFeed(ctx, url, retries, waitRateLimit, func() (err error) {
+		resp, err = fetchFeed(ctx, url, timeout, etag)
+		return err
+	})
+	return resp, err
+}
+
+// openWithRetry is fetchWithRetry for openFeed. Only the request is retried;
+// a failure while the body is read is the caller's.
+func openWithRetry(ctx context.Context, url string, timeout time.Duration, retries int, etag string, waitRateLimit bool) (feedStream, error) {
+	var stream feedStream
+	err := retryFeed(ctx, url, retries, waitRateLimit, func() (err error) {
+		stream, err = openFeed(ctx, url, timeout, etag)
+		return err
+	})
+	return stream, err
+}
+
+// retryFeed runs attempt until it succeeds, retrying as fetchWithRetry
+// describes.
+func retryFeed(ctx context.Context, url string, retries int, waitRateLimit bool, attempt func() error) error {
+	delay := retryBaseDelay
+
+	for try := 0; ; try++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		wait := delay
+		var temporary temporaryError
+		var rateLimit rateLimitError
+		switch {
+		case errors.As(err, &rateLimit) && waitRateLimit:
+			if rateLimit.retryAfter > 0 {
+				wait = rateLimit.retryAfter
+			}
+		case !errors.As(err, &temporary):
+			return err
+		}
+		if try == retries {
+			return fmt.Errorf("giving up after %d attempts: %w", try+1, err)
+		}
+
+		slog.Warn("Fetch failed, retrying", "feed", url, "attempt", try+1, "delay", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
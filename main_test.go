@@ -1,15 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFetchEarthquakeData(t *testing.T) {
-	// Store the original API URL
-	originalURL := EarthquakeAPIURL
-
 	// Create a test server to mock the API response
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -45,12 +55,13 @@ func TestFetchEarthquakeData(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Override the API URL with the test server's URL
-	EarthquakeAPIURL = server.URL
-
-	earthquakeData, err := fetchEarthquakeData()
+	resp, err := fetchFeed(context.Background(), server.URL, defaultTimeout, "")
+	if err != nil {
+		t.Fatalf("fetchFeed() returned an error: %v", err)
+	}
+	earthquakeData, err := decodeEarthquakeData(bytes.NewReader(resp.body))
 	if err != nil {
-		t.Errorf("fetchEarthquakeData() returned an error: %v", err)
+		t.Errorf("decodeEarthquakeData() returned an error: %v", err)
 	}
 
 	// Write more test cases for other scenarios as needed
@@ -58,6 +69,556 @@ func TestFetchEarthquakeData(t *testing.T) {
 		t.Errorf("Expected 2 earthquake features, got %d", len(earthquakeData.Features))
 	}
 
-	// Reset the EarthquakeAPIURL to the original value after the test
-	EarthquakeAPIURL = originalURL
+	if earthquakeData.Meta.Count != 2 || earthquakeData.Meta.Generated != 1633455637000 {
+		t.Errorf("Expected metadata to be decoded, got %+v", earthquakeData.Meta)
+	}
+}
+
+func TestLoadEarthquakeDataFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	data := `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"mag": 4.2, "place": "Location 1"}}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	earthquakeData, _, err := loadEarthquakeData(context.Background(), options{inputPath: path})
+	if err != nil {
+		t.Fatalf("loadEarthquakeData() returned an error: %v", err)
+	}
+	if len(earthquakeData.Features) != 1 || earthquakeData.Features[0].Properties.Place != "Location 1" {
+		t.Errorf("Unexpected features decoded from file: %+v", earthquakeData.Features)
+	}
+}
+
+func TestDecodeNullMagnitude(t *testing.T) {
+	earthquakeData, err := decodeEarthquakeData(strings.NewReader(`{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "properties": {"mag": null, "place": "Location 1"}},
+			{"type": "Feature", "properties": {"mag": 0, "place": "Location 2"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to decode features: %v", err)
+	}
+
+	unknown := newQuakeRecord(earthquakeData.Features[0])
+	if !unknown.unknownMag {
+		t.Errorf("Expected a null magnitude to be unknown, got %+v", unknown)
+	}
+	zero := newQuakeRecord(earthquakeData.Features[1])
+	if zero.unknownMag || zero.Mag != 0 {
+		t.Errorf("Expected magnitude 0 to be kept, got %+v", zero)
+	}
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, unknown, options{location: time.UTC})
+	if !strings.Contains(buf.String(), "Magnitude: unknown\n") {
+		t.Errorf("Expected an unknown magnitude line, got:\n%s", buf.String())
+	}
+}
+
+func TestDecodeFelt(t *testing.T) {
+	var earthquakeData Earthquake
+	err := json.Unmarshal([]byte(`{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "properties": {"mag": 4.1, "felt": null}},
+			{"type": "Feature", "properties": {"mag": 5.2, "felt": 0}},
+			{"type": "Feature", "properties": {"mag": 6.3, "felt": 1250}}
+		]
+	}`), &earthquakeData)
+	if err != nil {
+		t.Fatalf("Failed to decode features: %v", err)
+	}
+
+	if earthquakeData.Features[0].Properties.Felt != nil {
+		t.Errorf("Expected nil felt for JSON null, got %d", *earthquakeData.Features[0].Properties.Felt)
+	}
+	if felt := earthquakeData.Features[1].Properties.Felt; felt == nil || *felt != 0 {
+		t.Errorf("Expected felt 0 to be kept, got %v", felt)
+	}
+	if felt := earthquakeData.Features[2].Properties.Felt; felt == nil || *felt != 1250 {
+		t.Errorf("Expected felt 1250, got %v", felt)
+	}
+}
+
+func TestFetchFeedTimeout(t *testing.T) {
+	// Create a test server that answers slower than the client timeout
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	_, err := fetchFeed(context.Background(), server.URL, 10*time.Millisecond, "")
+	if err == nil {
+		t.Fatal("fetchFeed() expected a timeout error, got nil")
+	}
+	if !strings.Contains(err.Error(), "timed out after 10ms") {
+		t.Errorf("Expected a timeout error message, got %q", err)
+	}
+}
+
+func TestFetchFeedUserAgent(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.UserAgent()
+		w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+	}))
+	defer server.Close()
+
+	if _, err := fetchFeed(context.Background(), server.URL, defaultTimeout, ""); err != nil {
+		t.Fatalf("fetchFeed() returned an error: %v", err)
+	}
+
+	expected := "eqk/dev (+github.com/mpinheir/eqk)"
+	if received != expected {
+		t.Errorf("Expected User-Agent %q, got %q", expected, received)
+	}
+}
+
+func TestFetchFeedDebugDump(t *testing.T) {
+	// Store the original debug output
+	originalOutput := debugOutput
+	defer func() { debugOutput = originalOutput }()
+
+	const feed = `{"type": "FeatureCollection", "features": []}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	debugOutput = &buf
+
+	if _, err := fetchFeed(context.Background(), server.URL, defaultTimeout, ""); err != nil {
+		t.Fatalf("fetchFeed() returned an error: %v", err)
+	}
+
+	expected := fmt.Sprintf("----- %s (%d bytes)\n%s\n-----\n", server.URL, len(feed), feed)
+	if buf.String() != expected {
+		t.Errorf("Expected dump %q, got %q", expected, buf.String())
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	originalVersion, originalCommit, originalDate := version, commit, date
+	defer func() { version, commit, date = originalVersion, originalCommit, originalDate }()
+
+	version, commit, date = "1.2.3", "abc1234", "2024-01-02"
+
+	expected := "eqk 1.2.3 (commit abc1234, built 2024-01-02, " + runtime.Version() + ")"
+	if got := versionString(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFetchFeedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("<html>Service Unavailable</html>"))
+	}))
+	defer server.Close()
+
+	_, err := fetchFeed(context.Background(), server.URL, defaultTimeout, "")
+	if err == nil {
+		t.Fatal("fetchFeed() expected an error for status 503, got nil")
+	}
+
+	expected := "unexpected status 503 from USGS: <html>Service Unavailable</html>"
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err)
+	}
+}
+
+func TestFetchWithRetry(t *testing.T) {
+	// Store the original retry delay
+	originalDelay := retryBaseDelay
+	defer func() { retryBaseDelay = originalDelay }()
+	retryBaseDelay = time.Millisecond
+
+	tests := []struct {
+		name          string
+		failures      int
+		status        int
+		retries       int
+		expectError   bool
+		expectedCalls int
+	}{
+		{"recovers from 5xx", 2, http.StatusServiceUnavailable, 3, false, 3},
+		{"gives up after retries", 5, http.StatusBadGateway, 2, true, 3},
+		{"does not retry 4xx", 5, http.StatusNotFound, 3, true, 1},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= tt.failures {
+				w.WriteHeader(tt.status)
+				return
+			}
+			w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+		}))
+
+		_, err := fetchWithRetry(context.Background(), server.URL, defaultTimeout, tt.retries, "", false)
+		server.Close()
+
+		if tt.expectError && err == nil {
+			t.Errorf("%s: expected an error, got nil", tt.name)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if calls != tt.expectedCalls {
+			t.Errorf("%s: expected %d requests, got %d", tt.name, tt.expectedCalls, calls)
+		}
+	}
+}
+
+func TestNewRateLimitError(t *testing.T) {
+	originalNow := timeNow
+	defer func() { timeNow = originalNow }()
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	tests := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"120", 2 * time.Minute},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := newRateLimitError(tt.header).retryAfter; got != tt.expected {
+			t.Errorf("Retry-After %q: expected %s, got %s", tt.header, tt.expected, got)
+		}
+	}
+
+	if msg := newRateLimitError("120").Error(); !strings.Contains(msg, "try again in 2m0s") {
+		t.Errorf("Expected the error to advise the delay, got %q", msg)
+	}
+}
+
+func TestFetchWithRetryRateLimit(t *testing.T) {
+	// Store the original retry delay
+	originalDelay := retryBaseDelay
+	defer func() { retryBaseDelay = originalDelay }()
+	retryBaseDelay = time.Millisecond
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("slow down"))
+			return
+		}
+		w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+	}))
+	defer server.Close()
+
+	_, err := fetchWithRetry(context.Background(), server.URL, defaultTimeout, 3, "", false)
+	var rateLimit rateLimitError
+	if !errors.As(err, &rateLimit) || calls != 1 {
+		t.Errorf("Expected a rate limit error without retrying, got %v after %d requests", err, calls)
+	}
+
+	calls = 0
+	if _, err := fetchWithRetry(context.Background(), server.URL, defaultTimeout, 3, "", true); err != nil || calls != 2 {
+		t.Errorf("Expected a retry after the rate limit, got %v after %d requests", err, calls)
+	}
+}
+
+func TestFetchFeedGzip(t *testing.T) {
+	feed := `{"type": "FeatureCollection", "features": []}`
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(feed))
+	gz.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+		expected string
+	}{
+		{"gzip", "gzip", compressed.Bytes(), http.StatusOK, feed},
+		{"plain", "", []byte(feed), http.StatusOK, feed},
+		{"gzip error page", "gzip", compressed.Bytes(), http.StatusNotFound, "unexpected status 404 from USGS: " + feed},
+		{"corrupt gzip", "gzip", []byte(feed), http.StatusOK, "invalid gzip response"},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Accept-Encoding") != "gzip" {
+				t.Errorf("%s: expected Accept-Encoding gzip, got %q", tt.name, r.Header.Get("Accept-Encoding"))
+			}
+			if tt.encoding != "" {
+				w.Header().Set("Content-Encoding", tt.encoding)
+			}
+			w.WriteHeader(tt.status)
+			w.Write(tt.body)
+		}))
+
+		resp, err := fetchFeed(context.Background(), server.URL, defaultTimeout, "")
+		server.Close()
+
+		got := string(resp.body)
+		if err != nil {
+			got = err.Error()
+		}
+		if !strings.Contains(got, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestFetchFeedCanceled(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := fetchWithRetry(ctx, server.URL, defaultTimeout, 3, "", false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestListQuakes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	data := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"mag": 4.2, "place": "Location 1"}},
+		{"type": "Feature", "properties": {"mag": 6.1, "place": "Location 2"}}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	var buf bytes.Buffer
+	count, err := listQuakes(context.Background(), &buf, options{inputPath: path, minimumMagnitude: 5, maximumMagnitude: 10, countOnly: true})
+	if err != nil {
+		t.Fatalf("listQuakes() returned an error: %v", err)
+	}
+	if count != 1 || buf.String() != "1\n" {
+		t.Errorf("Expected 1 match, got %d and output %q", count, buf.String())
+	}
+}
+
+func TestListQuakesTop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	data := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"mag": 4.2, "place": "Location 1"}},
+		{"type": "Feature", "properties": {"mag": null, "place": "Location 2"}},
+		{"type": "Feature", "properties": {"mag": 6.1, "place": "Location 3"}},
+		{"type": "Feature", "properties": {"mag": 2.0, "place": "Location 4"}},
+		{"type": "Feature", "properties": {"mag": 5.5, "place": "Location 5"}}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	opts, err := parseFlags([]string{"-file", path, "-min", "3", "-top", "2", "-tz", "UTC"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	var buf bytes.Buffer
+	count, err := listQuakes(context.Background(), &buf, opts)
+	if err != nil {
+		t.Fatalf("listQuakes() returned an error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 matches, got %d", count)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "#1\nEpicenter = Location 3\n") || !strings.Contains(output, "#2\nEpicenter = Location 5\n") || strings.Contains(output, "#3") {
+		t.Errorf("Expected the 2 strongest earthquakes ranked, got:\n%s", output)
+	}
+}
+
+func TestListQuakesFetchError(t *testing.T) {
+	_, err := listQuakes(context.Background(), io.Discard, options{inputPath: filepath.Join(t.TempDir(), "missing.geojson")})
+	if err == nil {
+		t.Fatal("listQuakes() expected an error for a missing file, got nil")
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the file error to be wrapped, got %v", err)
+	}
+}
+
+func TestFetchFeeds(t *testing.T) {
+	// Store the original cache directory and retry delay
+	originalCacheDir := cacheDir
+	originalDelay := retryBaseDelay
+	defer func() {
+		cacheDir = originalCacheDir
+		retryBaseDelay = originalDelay
+	}()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+	retryBaseDelay = time.Millisecond
+
+	feed := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+	}
+	week := feed(`{"type": "FeatureCollection", "metadata": {"generated": 100}, "features": [
+		{"type": "Feature", "id": "us1", "properties": {"mag": 6.1, "updated": 1}},
+		{"type": "Feature", "id": "us2", "properties": {"mag": 7.0, "updated": 1}}
+	]}`)
+	defer week.Close()
+	day := feed(`{"type": "FeatureCollection", "metadata": {"generated": 200}, "features": [
+		{"type": "Feature", "id": "us1", "properties": {"mag": 6.2, "updated": 2}},
+		{"type": "Feature", "id": "us3", "properties": {"mag": 4.6, "updated": 1}}
+	]}`)
+	defer day.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer broken.Close()
+
+	opts := options{timeout: defaultTimeout}
+
+	earthquakeData, _, err := fetchFeeds(context.Background(), []string{week.URL, day.URL, broken.URL}, opts)
+	if err != nil {
+		t.Fatalf("fetchFeeds() returned an error: %v", err)
+	}
+	if len(earthquakeData.Features) != 3 || earthquakeData.Meta.Count != 3 {
+		t.Fatalf("Expected 3 merged features, got %+v", earthquakeData.Features)
+	}
+	if *earthquakeData.Features[0].Properties.Mag != 6.2 {
+		t.Errorf("Expected the latest revision of us1, got %+v", earthquakeData.Features[0])
+	}
+	if earthquakeData.Meta.Generated != 200 {
+		t.Errorf("Expected the newest generation time, got %d", earthquakeData.Meta.Generated)
+	}
+
+	_, _, err = fetchFeeds(context.Background(), []string{broken.URL, broken.URL + "/other"}, opts)
+	if err == nil {
+		t.Fatal("fetchFeeds() expected an error when every feed fails, got nil")
+	}
+	if !strings.Contains(err.Error(), broken.URL+":") || !strings.Contains(err.Error(), broken.URL+"/other:") {
+		t.Errorf("Expected every failure to be reported, got %v", err)
+	}
+}
+
+// roundTripFunc stubs an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestFetchFeedStubbedClient(t *testing.T) {
+	originalClient := httpClient
+	defer func() { httpClient = originalClient }()
+
+	var requested string
+	httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requested = r.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"type": "FeatureCollection", "features": [{"type": "Feature", "id": "us1"}]}`)),
+			Request:    r,
+		}, nil
+	})}
+
+	const feedURL = feedBaseURL + defaultFeed + ".geojson"
+	resp, err := fetchFeed(context.Background(), feedURL, defaultTimeout, "")
+	if err != nil {
+		t.Fatalf("fetchFeed() returned an error: %v", err)
+	}
+	earthquakeData, err := decodeEarthquakeData(bytes.NewReader(resp.body))
+	if err != nil {
+		t.Fatalf("decodeEarthquakeData() returned an error: %v", err)
+	}
+	if requested != feedURL {
+		t.Errorf("Expected a request for %q, got %q", feedURL, requested)
+	}
+	if len(earthquakeData.Features) != 1 || earthquakeData.Features[0].ID != "us1" {
+		t.Errorf("Unexpected features: %+v", earthquakeData.Features)
+	}
+}
+
+func TestRunMainExitCodes(t *testing.T) {
+	// Store the original logger and feed options runMain sets
+	originalLogger := slog.Default()
+	originalKeep := keepFeatureJSON
+	defer func() {
+		slog.SetDefault(originalLogger)
+		keepFeatureJSON = originalKeep
+	}()
+
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	data := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"mag": 4.2, "place": "Location 1"}}
+	]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"matches", []string{"-file", path, "-count-only"}, exitMatches},
+		{"no matches", []string{"-file", path, "-count-only", "-min", "5"}, exitNoMatches},
+		{"usage error", []string{"-bogus"}, exitError},
+		{"invalid flag value", []string{"-limit", "-1"}, exitError},
+		{"fetch error", []string{"-file", filepath.Join(t.TempDir(), "missing.geojson")}, exitError},
+		{"help", []string{"-h"}, exitMatches},
+		{"version", []string{"-version"}, exitMatches},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runMain(tt.args, &stdout, &stderr); code != tt.code {
+				t.Errorf("Expected exit code %d for %v, got %d", tt.code, tt.args, code)
+			}
+		})
+	}
+}
+
+func TestRunMainTimezoneWarningFollowsLogFormat(t *testing.T) {
+	originalLogger := slog.Default()
+	originalKeep := keepFeatureJSON
+	defer func() {
+		slog.SetDefault(originalLogger)
+		keepFeatureJSON = originalKeep
+	}()
+
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	if err := os.WriteFile(path, []byte(`{"type": "FeatureCollection", "features": []}`), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	runMain([]string{"-file", path, "-tz", "Nowhere/Land", "-log-format", "json"}, &stdout, &stderr)
+	var record map[string]interface{}
+	if err := json.Unmarshal(stderr.Bytes(), &record); err != nil {
+		t.Fatalf("Expected the time zone warning as a JSON record, got %q: %v", stderr.String(), err)
+	}
+	if record["level"] != "WARN" || record["msg"] != "Unknown time zone, using UTC" {
+		t.Errorf("Unexpected record: %v", record)
+	}
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// quakeMetrics holds the gauges exposed by -metrics, in the Prometheus text
+// exposition format. A nil *quakeMetrics ignores every update, so watch mode
+// can call it unconditionally.
+type quakeMetrics struct {
+	mu sync.Mutex
+
+	lastFetch    time.Time
+	fetchErrors  int
+	buckets      []histogramBucket
+	maxMagnitude float64
+	hasMagnitude bool
+}
+
+// fetched records a successful poll that returned unchanged data.
+func (m *quakeMetrics) fetched() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastFetch = timeNow()
+}
+
+// observe records a successful poll and the earthquakes it matched.
+func (m *quakeMetrics) observe(quakes []QuakeRecord) {
+	if m == nil {
+		return
+	}
+	stats, _ := computeStats(quakes)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastFetch = timeNow()
+	m.buckets = magnitudeHistogram(quakes)
+	m.maxMagnitude, m.hasMagnitude = stats.strongest.Mag, stats.hasMagnitude
+}
+
+// fetchFailed counts a failed poll.
+func (m *quakeMetrics) fetchFailed() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetchErrors++
+}
+
+// ServeHTTP writes the current values.
+func (m *quakeMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP eqk_last_fetch_timestamp_seconds Time of the last successful feed fetch.")
+	fmt.Fprintln(w, "# TYPE eqk_last_fetch_timestamp_seconds gauge")
+	lastFetch := 0.0
+	if !m.lastFetch.IsZero() {
+		lastFetch = float64(m.lastFetch.UnixMilli()) / 1000
+	}
+	fmt.Fprintf(w, "eqk_last_fetch_timestamp_seconds %s\n", formatFloat(lastFetch))
+
+	fmt.Fprintln(w, "# HELP eqk_events Matching earthquakes in the feed by whole magnitude.")
+	fmt.Fprintln(w, "# TYPE eqk_events gauge")
+	for _, bucket := range m.buckets {
+		fmt.Fprintf(w, "eqk_events{magnitude=\"%d\"} %d\n", bucket.low, bucket.count)
+	}
+
+	fmt.Fprintln(w, "# HELP eqk_max_magnitude Strongest matching earthquake in the feed.")
+	fmt.Fprintln(w, "# TYPE eqk_max_magnitude gauge")
+	maxMagnitude := math.NaN()
+	if m.hasMagnitude {
+		maxMagnitude = m.maxMagnitude
+	}
+	fmt.Fprintf(w, "eqk_max_magnitude %s\n", formatFloat(maxMagnitude))
+
+	fmt.Fprintln(w, "# HELP eqk_fetch_errors_total Failed feed fetches.")
+	fmt.Fprintln(w, "# TYPE eqk_fetch_errors_total counter")
+	fmt.Fprintf(w, "eqk_fetch_errors_total %d\n", m.fetchErrors)
+}
+
+// serveMetrics starts the -metrics server on addr in the background. A server
+// that cannot listen is logged; watch mode carries on without it.
+func serveMetrics(addr string, m *quakeMetrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics server failed", "addr", addr, "err", err)
+		}
+	}()
+	return server
+}
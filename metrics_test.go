@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuakeMetrics(t *testing.T) {
+	originalNow := timeNow
+	defer func() { timeNow = originalNow }()
+	timeNow = func() time.Time { return time.Unix(1700000000, 500*int64(time.Millisecond)) }
+
+	metrics := &quakeMetrics{}
+	metrics.observe([]QuakeRecord{{Mag: 4.2}, {Mag: 6.8}, {Mag: 4.9}, {unknownMag: true}})
+	metrics.fetchFailed()
+	metrics.fetchFailed()
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	for _, line := range []string{
+		"eqk_last_fetch_timestamp_seconds 1700000000.5\n",
+		"eqk_events{magnitude=\"4\"} 2\n",
+		"eqk_events{magnitude=\"5\"} 0\n",
+		"eqk_events{magnitude=\"6\"} 1\n",
+		"eqk_max_magnitude 6.8\n",
+		"eqk_fetch_errors_total 2\n",
+		"# TYPE eqk_fetch_errors_total counter\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected %q in metrics:\n%s", line, body)
+		}
+	}
+}
+
+func TestQuakeMetricsNil(t *testing.T) {
+	// Watch mode calls these without checking whether -metrics was given
+	var metrics *quakeMetrics
+	metrics.observe([]QuakeRecord{{Mag: 5}})
+	metrics.fetched()
+	metrics.fetchFailed()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notify shows a desktop notification. It is a variable so tests can stub it.
+var notify = desktopNotify
+
+// desktopNotify shells out to the platform notifier: notify-send on Linux and
+// osascript on macOS.
+func desktopNotify(title, body string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.Command("notify-send", title, body)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if _, err := exec.LookPath(cmd.Args[0]); err != nil {
+		return err
+	}
+	return cmd.Run()
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// notifyQuakes sends a notification for each earthquake at or above the
+// -notify magnitude. Failures are logged rather than aborting the run.
+func notifyQuakes(quakes []QuakeRecord, opts options) {
+	if opts.notifyMagnitude <= 0 {
+		return
+	}
+
+	for _, quake := range quakes {
+		if quake.unknownMag || quake.Mag < opts.notifyMagnitude {
+			continue
+		}
+		title := fmt.Sprintf("M%.1f earthquake", quake.Mag)
+		if err := notify(title, quake.Place); err != nil {
+			slog.Warn("Skipping desktop notification", "err", err)
+			return
+		}
+	}
+}
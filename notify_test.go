@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestNotifyQuakes(t *testing.T) {
+	originalNotify := notify
+	defer func() { notify = originalNotify }()
+
+	var titles, bodies []string
+	notify = func(title, body string) error {
+		titles = append(titles, title)
+		bodies = append(bodies, body)
+		return nil
+	}
+
+	quakes := []QuakeRecord{
+		{Place: "Location 1", Mag: 5.9},
+		{Place: "Location 2", Mag: 6.0},
+		{Place: "Location 3", Mag: 7.2},
+	}
+	notifyQuakes(quakes, options{notifyMagnitude: 6})
+
+	if len(titles) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d", len(titles))
+	}
+	if titles[0] != "M6.0 earthquake" || bodies[0] != "Location 2" {
+		t.Errorf("Unexpected first notification: %q / %q", titles[0], bodies[0])
+	}
+
+	titles = nil
+	notifyQuakes(quakes, options{})
+	if len(titles) != 0 {
+		t.Errorf("Expected no notifications when -notify is unset, got %d", len(titles))
+	}
+}
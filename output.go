@@ -0,0 +1,577 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats accepted by the -format flag.
+const (
+	formatText = "text"
+	formatCSV  = "csv"
+	formatJSON = "json"
+	formatMD   = "md"
+	formatHTML = "html"
+
+	formatGeoJSON = "geojson"
+	formatKML     = "kml"
+	formatYAML    = "yaml"
+	formatJSONL   = "jsonl"
+	formatICS     = "ics"
+	formatRSS     = "rss"
+	formatTable   = "table"
+)
+
+// outputFormats lists every supported output format.
+var outputFormats = []string{formatText, formatCSV, formatJSON, formatMD, formatHTML, formatGeoJSON, formatKML, formatYAML, formatJSONL, formatICS, formatRSS, formatTable}
+
+// Coordinate styles accepted by the -coords flag.
+const (
+	coordinatesDecimal = "decimal"
+	coordinatesDMS     = "dms"
+)
+
+// Unit systems accepted by the -units flag.
+const (
+	unitsMetric   = "metric"
+	unitsImperial = "imperial"
+)
+
+// Depth classes use the standard seismological bins: shallow above 70 km,
+// intermediate down to 300 km and deep below that.
+const (
+	shallowDepthLimit      = 70.0
+	intermediateDepthLimit = 300.0
+)
+
+// timeNow is the clock relative times are measured against; tests replace it.
+var timeNow = time.Now
+
+// dateFormat is how times are shown in the text report.
+const dateFormat = "2006-01-02 15:04:05 MST"
+
+// QuakeRecord is the flattened view of a single earthquake shared by all output formats.
+type QuakeRecord struct {
+	ID        string    `json:"id" yaml:"id"`
+	Place     string    `json:"place" yaml:"place"`
+	Mag       float64   `json:"mag" yaml:"mag"`
+	MagType   string    `json:"magType,omitempty" yaml:"magType,omitempty"`
+	Time      time.Time `json:"time" yaml:"time"`
+	Updated   time.Time `json:"updated" yaml:"updated"`
+	Longitude float64   `json:"longitude" yaml:"longitude"`
+	Latitude  float64   `json:"latitude" yaml:"latitude"`
+	Depth     float64   `json:"depth" yaml:"depth"`
+
+	Tsunami bool   `json:"tsunami" yaml:"tsunami"`
+	Alert   string `json:"alert,omitempty" yaml:"alert,omitempty"`
+	Status  string `json:"status,omitempty" yaml:"status,omitempty"`
+	Net     string `json:"net,omitempty" yaml:"net,omitempty"`
+	Sig     int    `json:"sig" yaml:"sig"`
+	Felt    *int   `json:"felt" yaml:"felt"`
+	URL     string `json:"url" yaml:"url"`
+
+	// Distance from the -near reference point in km, if one was given
+	Distance *float64 `json:"distance,omitempty" yaml:"distance,omitempty"`
+
+	hasLocation bool
+	hasDepth    bool
+
+	// unknownMag is set when the feed gave a null magnitude; Mag is then 0
+	unknownMag bool
+
+	// feature is the feed feature the record was built from
+	feature Feature
+}
+
+// newQuakeRecord flattens a feed feature.
+func newQuakeRecord(feature Feature) QuakeRecord {
+	quake := QuakeRecord{
+		ID:      feature.ID,
+		Place:   feature.Properties.Place,
+		MagType: feature.Properties.MagType,
+		Time:    time.UnixMilli(feature.Properties.Time).UTC(),
+		Updated: time.UnixMilli(feature.Properties.Updated).UTC(),
+
+		Tsunami: feature.Properties.Tsunami == 1,
+		Alert:   feature.Properties.Alert,
+		Status:  feature.Properties.Status,
+		Net:     feature.Properties.Net,
+		Sig:     feature.Properties.Sig,
+		Felt:    feature.Properties.Felt,
+		URL:     feature.Properties.URL,
+	}
+	mag, ok := feature.Properties.magnitude()
+	quake.Mag, quake.unknownMag = mag, !ok
+	quake.setCoordinates(feature.Geometry.Coordinates)
+	quake.feature = feature
+
+	return quake
+}
+
+// quakeJSON is the -format json, yaml and jsonl form of a QuakeRecord, with
+// the same fields in the same order, except that a magnitude, epicenter or
+// depth the feed did not give is null rather than 0.
+type quakeJSON struct {
+	ID        string    `json:"id" yaml:"id"`
+	Place     string    `json:"place" yaml:"place"`
+	Mag       *float64  `json:"mag" yaml:"mag"`
+	MagType   string    `json:"magType,omitempty" yaml:"magType,omitempty"`
+	Time      time.Time `json:"time" yaml:"time"`
+	Updated   time.Time `json:"updated" yaml:"updated"`
+	Longitude *float64  `json:"longitude" yaml:"longitude"`
+	Latitude  *float64  `json:"latitude" yaml:"latitude"`
+	Depth     *float64  `json:"depth" yaml:"depth"`
+
+	Tsunami bool   `json:"tsunami" yaml:"tsunami"`
+	Alert   string `json:"alert,omitempty" yaml:"alert,omitempty"`
+	Status  string `json:"status,omitempty" yaml:"status,omitempty"`
+	Net     string `json:"net,omitempty" yaml:"net,omitempty"`
+	Sig     int    `json:"sig" yaml:"sig"`
+	Felt    *int   `json:"felt" yaml:"felt"`
+	URL     string `json:"url" yaml:"url"`
+
+	Distance *float64 `json:"distance,omitempty" yaml:"distance,omitempty"`
+}
+
+// structured returns the quakeJSON form of q.
+func (q QuakeRecord) structured() quakeJSON {
+	s := quakeJSON{
+		ID:       q.ID,
+		Place:    q.Place,
+		MagType:  q.MagType,
+		Time:     q.Time,
+		Updated:  q.Updated,
+		Tsunami:  q.Tsunami,
+		Alert:    q.Alert,
+		Status:   q.Status,
+		Net:      q.Net,
+		Sig:      q.Sig,
+		Felt:     q.Felt,
+		URL:      q.URL,
+		Distance: q.Distance,
+	}
+	if !q.unknownMag {
+		mag := q.Mag
+		s.Mag = &mag
+	}
+	if q.hasLocation {
+		longitude, latitude := q.Longitude, q.Latitude
+		s.Longitude, s.Latitude = &longitude, &latitude
+	}
+	if q.hasDepth {
+		depth := q.Depth
+		s.Depth = &depth
+	}
+	return s
+}
+
+// MarshalJSON writes the quakeJSON form of q.
+func (q QuakeRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.structured())
+}
+
+// MarshalYAML writes the quakeJSON form of q.
+func (q QuakeRecord) MarshalYAML() (any, error) {
+	return q.structured(), nil
+}
+
+// setCoordinates copies a GeoJSON [longitude, latitude, depth] array into the
+// record, tolerating features that carry fewer values.
+func (q *QuakeRecord) setCoordinates(coordinates []float64) {
+	if len(coordinates) >= 2 {
+		q.Longitude = coordinates[0]
+		q.Latitude = coordinates[1]
+		q.hasLocation = true
+	}
+	if len(coordinates) >= 3 {
+		q.Depth = coordinates[2]
+		q.hasDepth = true
+	}
+}
+
+// histogramWidth is the length of the longest histogram bar.
+const histogramWidth = 50
+
+// printHistogram draws one bar of '#' per magnitude bucket, scaled so the
+// fullest bucket spans histogramWidth.
+func printHistogram(w io.Writer, quakes []QuakeRecord) {
+	buckets := magnitudeHistogram(quakes)
+	if len(buckets) == 0 {
+		return
+	}
+
+	largest := 0
+	for _, bucket := range buckets {
+		if bucket.count > largest {
+			largest = bucket.count
+		}
+	}
+
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	for _, bucket := range buckets {
+		bar := bucket.count * histogramWidth / largest
+		if bar == 0 && bucket.count > 0 {
+			bar = 1
+		}
+		fmt.Fprintf(w, "%4.1f-%-4.1f | %-*s %d\n", float64(bucket.low), float64(bucket.low)+0.9, histogramWidth, strings.Repeat("#", bar), bucket.count)
+	}
+}
+
+// printASCIIMap plots the earthquakes on an ASCII world map.
+func printASCIIMap(w io.Writer, quakes []QuakeRecord) {
+	features := make([]Feature, 0, len(quakes))
+	for _, quake := range quakes {
+		features = append(features, quake.feature)
+	}
+
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	fmt.Fprint(w, plotASCII(features, asciiMapWidth, asciiMapHeight))
+	fmt.Fprintln(w, ". below 5   o 5+   O 6+   @ 7+")
+}
+
+// timeField renders the origin time for tabular formats.
+func (q QuakeRecord) timeField() string {
+	return q.Time.Format(time.RFC3339)
+}
+
+// coordinateFields renders longitude and latitude for tabular formats, or
+// empty fields when the feature has no location.
+func (q QuakeRecord) coordinateFields() (longitude, latitude string) {
+	if !q.hasLocation {
+		return "", ""
+	}
+	return formatFloat(q.Longitude), formatFloat(q.Latitude)
+}
+
+// magField renders the magnitude for tabular formats, or an empty field when
+// it is unknown.
+func (q QuakeRecord) magField() string {
+	if q.unknownMag {
+		return ""
+	}
+	return formatFloat(q.Mag)
+}
+
+// depthField renders the depth in km for tabular formats, or an empty field
+// when unknown.
+func (q QuakeRecord) depthField() string {
+	if !q.hasDepth {
+		return ""
+	}
+	return formatFloat(q.Depth)
+}
+
+func validFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// printQuakes prints the human-readable list of earthquakes, headed by the
+// feed metadata when the feed carried any. total is the number of matches,
+// which exceeds len(quakes) when -limit applies.
+func printQuakes(w io.Writer, meta Metadata, quakes []QuakeRecord, total int, opts options) {
+	if meta.Generated != 0 {
+		generated := time.UnixMilli(meta.Generated).In(opts.location).Format(dateFormat)
+		fmt.Fprintf(w, "Feed generated: %s | USGS reports %d events\n", generated, meta.Count)
+	}
+	if opts.region != "" {
+		fmt.Fprintf(w, "Region %s: %s (minLon,minLat,maxLon,maxLat)\n", opts.region, opts.bbox)
+	}
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	fmt.Fprintf(w, "%s with magnitude %s, %s:\n", plural(total, "earthquake"), magnitudeRange(opts.minimumMagnitude, opts.maximumMagnitude), describeFeedPeriod(opts.feed))
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+
+	if opts.cluster {
+		for _, cluster := range clusterQuakes(quakes, opts.clusterRadius, opts.clusterWindow) {
+			fmt.Fprintf(w, "Cluster of %s: mainshock and %s\n", plural(len(cluster.aftershocks)+1, "earthquake"), plural(len(cluster.aftershocks), "aftershock"))
+			fmt.Fprintln(w, "-------------------------------------------------------------------")
+			printEarthquakeInfo(w, cluster.mainshock, opts)
+		}
+		return
+	}
+
+	if opts.groupByRegion {
+		for _, group := range groupByRegion(quakes) {
+			fmt.Fprintf(w, "%s: %s\n", group.region, plural(len(group.quakes), "earthquake"))
+			fmt.Fprintln(w, "-------------------------------------------------------------------")
+			for _, quake := range group.quakes {
+				printEarthquakeInfo(w, quake, opts)
+			}
+		}
+		return
+	}
+
+	for i, quake := range quakes {
+		if opts.top > 0 {
+			fmt.Fprintf(w, "#%d\n", i+1)
+		}
+		printEarthquakeInfo(w, quake, opts)
+	}
+}
+
+// printSummary follows the list with the total count and, when anything
+// matched, aggregate statistics over all matches. The list itself may show
+// fewer earthquakes when -limit applies.
+func printSummary(w io.Writer, quakes []QuakeRecord, shown int, opts options) {
+	if shown < len(quakes) {
+		fmt.Fprintf(w, "Showing %d of %d earthquakes\n", shown, len(quakes))
+	}
+	fmt.Fprintln(w, "Total number of Earthquakes: ", len(quakes))
+
+	stats, ok := computeStats(quakes)
+	if !ok {
+		return
+	}
+
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	if stats.hasMagnitude {
+		fmt.Fprintf(w, "Strongest: %.1f, %s\n", stats.strongest.Mag, stats.strongest.Place)
+		fmt.Fprintf(w, "Mean magnitude: %.2f\n", stats.meanMagnitude)
+		fmt.Fprintf(w, "Median magnitude: %.2f\n", stats.medianMagnitude)
+		if opts.energy {
+			fmt.Fprintln(w, "Total energy:", formatSI(stats.totalEnergy, "J"))
+		}
+	}
+	if stats.hasDepth {
+		fmt.Fprintf(w, "Shallowest: %s, %s\n", formatLength(stats.shallowest.Depth, 1, opts.units), stats.shallowest.Place)
+		fmt.Fprintf(w, "Deepest: %s, %s\n", formatLength(stats.deepest.Depth, 1, opts.units), stats.deepest.Place)
+	}
+}
+
+// magnitudeRange describes the magnitude filter for the report header.
+func magnitudeRange(minimumMagnitude, maximumMagnitude float64) string {
+	if math.IsInf(maximumMagnitude, 1) {
+		return fmt.Sprintf("%.1f or higher", minimumMagnitude)
+	}
+	return fmt.Sprintf("between %.1f and %.1f", minimumMagnitude, maximumMagnitude)
+}
+
+// printEarthquakeInfo prints a single earthquake block.
+func printEarthquakeInfo(w io.Writer, quake QuakeRecord, opts options) {
+	fmt.Fprintln(w, "Epicenter =", quake.Place)
+	if quake.unknownMag {
+		fmt.Fprintln(w, "Magnitude: unknown")
+	} else {
+		magnitude := fmt.Sprint("Magnitude: ", quake.Mag)
+		if quake.MagType != "" {
+			magnitude += " (" + quake.MagType + ")"
+		}
+		fmt.Fprintln(w, colorize(magnitude, quake.Mag, opts.colorize))
+		if opts.energy {
+			fmt.Fprintln(w, "Energy:", formatSI(energyJoules(quake.Mag), "J"))
+		}
+	}
+	if opts.relative {
+		fmt.Fprintf(w, "Time: %s (%s)\n", quake.Time.In(opts.location).Format(dateFormat), relativeTime(quake.Time, timeNow()))
+	} else {
+		fmt.Fprintln(w, "Time:", quake.Time.In(opts.location).Format(dateFormat))
+	}
+	if quake.Updated.After(quake.Time) {
+		fmt.Fprintln(w, "Updated:", quake.Updated.In(opts.location).Format(dateFormat))
+	}
+
+	if quake.hasLocation {
+		if opts.coordinates == coordinatesDMS {
+			fmt.Fprintf(w, "Coordinates: %s, %s\n", decimalToDMS(quake.Latitude, true), decimalToDMS(quake.Longitude, false))
+		} else {
+			fmt.Fprintf(w, "Coordinates: %.4f, %.4f\n", quake.Latitude, quake.Longitude)
+		}
+	} else {
+		// Malformed features carry fewer than two coordinates
+		fmt.Fprintln(w, "Coordinates: unavailable")
+	}
+	if opts.geohash && quake.hasLocation {
+		fmt.Fprintln(w, "Geohash:", geohash(quake.Latitude, quake.Longitude, opts.geohashPrecision))
+	}
+	if quake.hasDepth {
+		fmt.Fprintf(w, "Depth: %s (%s)\n", formatLength(quake.Depth, 1, opts.units), depthClass(quake.Depth))
+	}
+	if quake.Distance != nil {
+		distance := formatLength(*quake.Distance, 0, opts.units)
+		if opts.bearing {
+			// Reads like USGS's own "20 km S of Foo", from the other end
+			bearing := initialBearing(opts.nearLatitude, opts.nearLongitude, quake.Latitude, quake.Longitude)
+			distance += " " + compassPoint(bearing) + " of your location"
+		}
+		fmt.Fprintln(w, "Distance:", distance)
+	}
+	fmt.Fprintln(w, "Significance:", quake.Sig)
+	if quake.Felt != nil {
+		fmt.Fprintln(w, "Felt reports:", *quake.Felt)
+	}
+	fmt.Fprintln(w, "Tsunami:", yesNo(quake.Tsunami))
+	if quake.Alert != "" {
+		fmt.Fprintln(w, "Alert:", quake.Alert)
+	}
+	if quake.Status != "" {
+		fmt.Fprintln(w, "Status:", quake.Status)
+	}
+	if quake.Net != "" {
+		fmt.Fprintln(w, "Network:", quake.Net)
+	}
+	if quake.URL != "" {
+		fmt.Fprintln(w, "More info:", quake.URL)
+	}
+	if opts.maps && quake.hasLocation {
+		fmt.Fprintln(w, "Map:", mapsURL(quake.Latitude, quake.Longitude))
+	}
+
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+}
+
+// writeCSV writes one row of columns per earthquake, preceded by their
+// headers. encoding/csv quotes fields containing commas, such as most USGS
+// place names.
+func writeCSV(w io.Writer, quakes []QuakeRecord, columns []column) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(columnHeaders(columns)); err != nil {
+		return err
+	}
+
+	for _, quake := range quakes {
+		if err := csvWriter.Write(columnValues(quake, columns)); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// writeJSON writes the earthquakes as an indented JSON array.
+func writeJSON(w io.Writer, quakes []QuakeRecord) error {
+	if quakes == nil {
+		// Encode an empty result as [] rather than null
+		quakes = []QuakeRecord{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(quakes)
+}
+
+// writeJSONL writes one compact JSON object per earthquake per line, with
+// the same fields as writeJSON.
+func writeJSONL(w io.Writer, quakes []QuakeRecord) error {
+	encoder := json.NewEncoder(w)
+	for _, quake := range quakes {
+		if err := encoder.Encode(quake); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapsURL links to a Google Maps pin. Note the lat,lon order, the reverse of GeoJSON.
+func mapsURL(latitude, longitude float64) string {
+	return "https://www.google.com/maps?q=" + formatFloat(latitude) + "," + formatFloat(longitude)
+}
+
+// relativeTime describes t relative to now, e.g. "3h 12m ago".
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	if d < 0 {
+		return "in " + humanizeDuration(-d)
+	}
+	if d < time.Minute {
+		return "just now"
+	}
+	return humanizeDuration(d) + " ago"
+}
+
+// humanizeDuration renders d with its two most significant units, rounded
+// down: "45s", "12m", "3h 12m", "2d 5h".
+func humanizeDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	default:
+		return fmt.Sprintf("%dd %dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	}
+}
+
+// depthClass labels a depth in km as shallow, intermediate or deep.
+func depthClass(depth float64) string {
+	switch {
+	case depth < shallowDepthLimit:
+		return "shallow"
+	case depth <= intermediateDepthLimit:
+		return "intermediate"
+	default:
+		return "deep"
+	}
+}
+
+// formatLength renders a length given in km in the -units system, with its
+// unit label.
+func formatLength(km float64, decimals int, units string) string {
+	if units == unitsImperial {
+		return strconv.FormatFloat(kmToMiles(km), 'f', decimals, 64) + " mi"
+	}
+	return strconv.FormatFloat(km, 'f', decimals, 64) + " km"
+}
+
+// plural counts n of noun, adding an s unless n is 1: "1 earthquake",
+// "3 earthquakes".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// markdownEscaper keeps place names from breaking Markdown table cells.
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r", " ", "\n", " ")
+
+// writeMarkdown writes a GitHub-flavored Markdown table, linking each
+// coordinate pair to a map.
+func writeMarkdown(w io.Writer, quakes []QuakeRecord) error {
+	if _, err := fmt.Fprintln(w, "| Place | Mag | Depth | Time | Coordinates |"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "| --- | ---: | ---: | --- | --- |"); err != nil {
+		return err
+	}
+
+	for _, quake := range quakes {
+		coordinates := ""
+		if quake.hasLocation {
+			longitude, latitude := quake.coordinateFields()
+			coordinates = fmt.Sprintf("[%s, %s](%s)", latitude, longitude, mapsURL(quake.Latitude, quake.Longitude))
+		}
+
+		_, err := fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			markdownEscaper.Replace(quake.Place), quake.magField(), quake.depthField(), quake.timeField(), coordinates)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
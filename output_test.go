@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCSVQuotesPlace(t *testing.T) {
+	quake := QuakeRecord{
+		Place: "10km S of Something, CA",
+		Mag:   4.5,
+		Time:  time.UnixMilli(1633455600000).UTC(),
+	}
+	quake.setCoordinates([]float64{-117.5, 35.25, 8.1})
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, []QuakeRecord{quake}, mustParseColumns(t, defaultColumns)); err != nil {
+		t.Fatalf("writeCSV() returned an error: %v", err)
+	}
+
+	expected := "place,magnitude,time_utc,longitude,latitude,depth\n" +
+		"\"10km S of Something, CA\",4.5,2021-10-05T17:40:00Z,-117.5,35.25,8.1\n"
+	if buf.String() != expected {
+		t.Errorf("Expected CSV output %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteCSVMissingCoordinates(t *testing.T) {
+	quake := QuakeRecord{Place: "Location 1", Mag: 5}
+	quake.setCoordinates([]float64{-117.5})
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, []QuakeRecord{quake}, mustParseColumns(t, defaultColumns)); err != nil {
+		t.Fatalf("writeCSV() returned an error: %v", err)
+	}
+
+	if !strings.HasSuffix(buf.String(), ",,,\n") {
+		t.Errorf("Expected empty coordinate fields, got %q", buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	quake := QuakeRecord{
+		Place: "Location 1",
+		Mag:   6.5,
+		Time:  time.UnixMilli(1633455600000).UTC(),
+	}
+	quake.setCoordinates([]float64{142.1, 38.3, 10})
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, []QuakeRecord{quake}); err != nil {
+		t.Fatalf("writeJSON() returned an error: %v", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeJSON() produced invalid JSON: %v", err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("Expected 1 element, got %d", len(decoded))
+	}
+	if decoded[0]["time"] != "2021-10-05T17:40:00Z" {
+		t.Errorf("Expected RFC3339 UTC time, got %v", decoded[0]["time"])
+	}
+	if decoded[0]["depth"] != 10.0 {
+		t.Errorf("Expected depth 10, got %v", decoded[0]["depth"])
+	}
+
+	buf.Reset()
+	if err := writeJSON(&buf, nil); err != nil {
+		t.Fatalf("writeJSON() returned an error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected empty array for no matches, got %q", buf.String())
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	quakes := []QuakeRecord{{Place: "A", Mag: 4.5}, {Place: "B", Mag: 5.1}}
+
+	var buf bytes.Buffer
+	if err := writeJSONL(&buf, quakes); err != nil {
+		t.Fatalf("writeJSONL() returned an error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per earthquake, got %q", buf.String())
+	}
+	for i, line := range lines {
+		var decoded QuakeRecord
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("Line %d is not a JSON object: %v", i, err)
+		}
+		if decoded.Place != quakes[i].Place || decoded.Mag != quakes[i].Mag {
+			t.Errorf("Line %d: expected %+v, got %+v", i, quakes[i], decoded)
+		}
+	}
+
+	buf.Reset()
+	if err := writeJSONL(&buf, nil); err != nil || buf.Len() != 0 {
+		t.Errorf("Expected no output for no matches, got %q (%v)", buf.String(), err)
+	}
+}
+
+func TestWriteJSONUnknownMagnitude(t *testing.T) {
+	quakes := []QuakeRecord{{Place: "Known", Mag: 4.5}, {Place: "Unknown", unknownMag: true}}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, quakes); err != nil {
+		t.Fatalf("writeJSON() returned an error: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeJSON() produced invalid JSON: %v", err)
+	}
+	if mag, ok := decoded[1]["mag"]; !ok || mag != nil || decoded[0]["mag"] != 4.5 {
+		t.Errorf("Expected mag 4.5 and null, got %v and %v", decoded[0]["mag"], decoded[1]["mag"])
+	}
+
+	buf.Reset()
+	if err := writeJSONL(&buf, quakes); err != nil {
+		t.Fatalf("writeJSONL() returned an error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"mag":4.5`) || !strings.Contains(lines[1], `"mag":null`) {
+		t.Errorf("Expected mag 4.5 and null, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSONMissingCoordinates(t *testing.T) {
+	surface, nowhere := QuakeRecord{Place: "Surface"}, QuakeRecord{Place: "Nowhere"}
+	surface.setCoordinates([]float64{142.1, 38.3})
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, []QuakeRecord{surface, nowhere}); err != nil {
+		t.Fatalf("writeJSON() returned an error: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeJSON() produced invalid JSON: %v", err)
+	}
+	if decoded[0]["longitude"] != 142.1 || decoded[0]["latitude"] != 38.3 || decoded[0]["depth"] != nil {
+		t.Errorf("Expected the epicenter without a depth, got %v", decoded[0])
+	}
+	for _, key := range []string{"longitude", "latitude", "depth"} {
+		if value, ok := decoded[1][key]; !ok || value != nil {
+			t.Errorf("Expected a null %s, got %v", key, value)
+		}
+	}
+
+	buf.Reset()
+	if err := writeJSONL(&buf, []QuakeRecord{nowhere}); err != nil {
+		t.Fatalf("writeJSONL() returned an error: %v", err)
+	}
+	if line := buf.String(); !strings.Contains(line, `"longitude":null,"latitude":null,"depth":null`) {
+		t.Errorf("Expected null coordinates, got %s", line)
+	}
+}
+
+func TestMapsURL(t *testing.T) {
+	quake := QuakeRecord{}
+	quake.setCoordinates([]float64{-70.66, -33.45, 10})
+
+	expected := "https://www.google.com/maps?q=-33.45,-70.66"
+	if got := mapsURL(quake.Latitude, quake.Longitude); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestPrintEarthquakeInfo(t *testing.T) {
+	felt := 12
+	quake := QuakeRecord{
+		Place:   "Location 1",
+		Mag:     6.5,
+		MagType: "mww",
+		Time:    time.UnixMilli(1633455600000).UTC(),
+		Sig:     650,
+		Felt:    &felt,
+		Tsunami: true,
+		Alert:   "yellow",
+		URL:     "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+	}
+	quake.setCoordinates([]float64{142.1, 38.3, 10})
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, quake, options{location: time.UTC, maps: true})
+
+	expected := `Epicenter = Location 1
+Magnitude: 6.5 (mww)
+Time: 2021-10-05 17:40:00 UTC
+Coordinates: 38.3000, 142.1000
+Depth: 10.0 km (shallow)
+Significance: 650
+Felt reports: 12
+Tsunami: yes
+Alert: yellow
+More info: https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd
+Map: https://www.google.com/maps?q=38.3,142.1
+-------------------------------------------------------------------
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nExpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestPrintQuakesHeader(t *testing.T) {
+	quakes := []QuakeRecord{{Place: "Location 1", Mag: 5.2}}
+
+	var buf bytes.Buffer
+	printQuakes(&buf, Metadata{}, quakes, 3, options{minimumMagnitude: 5, maximumMagnitude: math.Inf(1), feed: "all_week", location: time.UTC})
+
+	expected := "3 earthquakes with magnitude 5.0 or higher, in the last 7 days:\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected header %q in output:\n%s", expected, buf.String())
+	}
+}
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		n        int
+		expected string
+	}{
+		{0, "0 earthquakes"},
+		{1, "1 earthquake"},
+		{12, "12 earthquakes"},
+	}
+
+	for _, tt := range tests {
+		if got := plural(tt.n, "earthquake"); got != tt.expected {
+			t.Errorf("plural(%d): expected %q, got %q", tt.n, tt.expected, got)
+		}
+	}
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 59*time.Second, "12m"},
+		{3*time.Hour + 12*time.Minute + 30*time.Second, "3h 12m"},
+		{53 * time.Hour, "2d 5h"},
+	}
+
+	for _, tt := range tests {
+		if got := humanizeDuration(tt.d); got != tt.expected {
+			t.Errorf("humanizeDuration(%s): expected %q, got %q", tt.d, tt.expected, got)
+		}
+	}
+}
+
+func TestPrintEarthquakeInfoRelative(t *testing.T) {
+	originalNow := timeNow
+	defer func() { timeNow = originalNow }()
+
+	origin := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return origin.Add(3*time.Hour + 12*time.Minute) }
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, QuakeRecord{Time: origin}, options{location: time.UTC, relative: true})
+
+	expected := "Time: 2024-01-02 12:00:00 UTC (3h 12m ago)\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected %q in output:\n%s", expected, buf.String())
+	}
+
+	for _, tt := range []struct {
+		offset   time.Duration
+		expected string
+	}{
+		{-20 * time.Second, "just now"},
+		{5 * time.Minute, "in 5m"},
+	} {
+		if got := relativeTime(origin.Add(tt.offset), origin); got != tt.expected {
+			t.Errorf("relativeTime(%s): expected %q, got %q", tt.offset, tt.expected, got)
+		}
+	}
+}
+
+func TestDepthClass(t *testing.T) {
+	tests := []struct {
+		depth    float64
+		expected string
+	}{
+		{-1.5, "shallow"},
+		{10, "shallow"},
+		{69.9, "shallow"},
+		{70, "intermediate"},
+		{300, "intermediate"},
+		{300.1, "deep"},
+		{650, "deep"},
+	}
+
+	for _, tt := range tests {
+		if got := depthClass(tt.depth); got != tt.expected {
+			t.Errorf("depthClass(%v): expected %q, got %q", tt.depth, tt.expected, got)
+		}
+	}
+}
+
+func TestFormatLength(t *testing.T) {
+	tests := []struct {
+		km       float64
+		decimals int
+		units    string
+		expected string
+	}{
+		{10, 1, unitsMetric, "10.0 km"},
+		{10, 1, unitsImperial, "6.2 mi"},
+		{160.9344, 0, unitsImperial, "100 mi"},
+	}
+
+	for _, tt := range tests {
+		if got := formatLength(tt.km, tt.decimals, tt.units); got != tt.expected {
+			t.Errorf("formatLength(%v, %d, %s): expected %q, got %q", tt.km, tt.decimals, tt.units, tt.expected, got)
+		}
+	}
+}
+
+func TestPrintEarthquakeInfoUpdated(t *testing.T) {
+	origin := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, QuakeRecord{Time: origin, Updated: origin}, options{location: time.UTC})
+	if strings.Contains(buf.String(), "Updated:") {
+		t.Errorf("Expected no updated line for an unrevised event, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	printEarthquakeInfo(&buf, QuakeRecord{Time: origin, Updated: origin.Add(90 * time.Minute)}, options{location: time.UTC})
+	if !strings.Contains(buf.String(), "Time: 2024-01-02 12:00:00 UTC\nUpdated: 2024-01-02 13:30:00 UTC\n") {
+		t.Errorf("Expected an updated line after the time, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintEarthquakeInfoWithoutCoordinates(t *testing.T) {
+	for _, coordinates := range [][]float64{nil, {142.1}} {
+		quake := newQuakeRecord(Feature{Geometry: Geometry{Coordinates: coordinates}})
+
+		var buf bytes.Buffer
+		printEarthquakeInfo(&buf, quake, options{location: time.UTC, maps: true})
+
+		if !strings.Contains(buf.String(), "Coordinates: unavailable\n") {
+			t.Errorf("Coordinates %v: expected unavailable coordinates, got:\n%s", coordinates, buf.String())
+		}
+		if strings.Contains(buf.String(), "Depth:") || strings.Contains(buf.String(), "Map:") {
+			t.Errorf("Coordinates %v: expected no depth or map link, got:\n%s", coordinates, buf.String())
+		}
+	}
+}
+
+func TestNewQuakeRecord(t *testing.T) {
+	mag := 5.4
+	feature := Feature{
+		ID: "us1000abcd",
+		Properties: Properties{
+			Mag:     &mag,
+			MagType: "mb",
+			Place:   "Location 1",
+			Time:    1633455600000,
+			Tsunami: 1,
+		},
+		Geometry: Geometry{Type: "Point", Coordinates: []float64{142.1, 38.3, 10}},
+	}
+
+	quake := newQuakeRecord(feature)
+
+	if quake.ID != "us1000abcd" || quake.Mag != 5.4 || quake.MagType != "mb" || !quake.Tsunami {
+		t.Errorf("Unexpected record: %+v", quake)
+	}
+	if !quake.Time.Equal(time.UnixMilli(1633455600000)) {
+		t.Errorf("Expected time %v, got %v", time.UnixMilli(1633455600000).UTC(), quake.Time)
+	}
+	if quake.Latitude != 38.3 || quake.Longitude != 142.1 || quake.Depth != 10 {
+		t.Errorf("Coordinates not copied in GeoJSON order: %+v", quake)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	quake := QuakeRecord{
+		Place: "Ridge | Rise, Pacific",
+		Mag:   5.2,
+		Time:  time.UnixMilli(1633455600000).UTC(),
+	}
+	quake.setCoordinates([]float64{-110.5, -20.25, 10})
+
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, []QuakeRecord{quake}); err != nil {
+		t.Fatalf("writeMarkdown() returned an error: %v", err)
+	}
+
+	expected := "| Place | Mag | Depth | Time | Coordinates |\n" +
+		"| --- | ---: | ---: | --- | --- |\n" +
+		"| Ridge \\| Rise, Pacific | 5.2 | 10 | 2021-10-05T17:40:00Z | [-20.25, -110.5](https://www.google.com/maps?q=-20.25,-110.5) |\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected Markdown:\n%s\nExpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestPrintEnergy(t *testing.T) {
+	quakes := []QuakeRecord{{Place: "A", Mag: 7}, {Place: "B", Mag: 4}, {Place: "C", unknownMag: true}}
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, quakes[0], options{location: time.UTC, energy: true})
+	if !strings.Contains(buf.String(), "Magnitude: 7\nEnergy: 2.0 PJ\n") {
+		t.Errorf("Expected the energy after the magnitude, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	printSummary(&buf, quakes, len(quakes), options{energy: true})
+	if !strings.Contains(buf.String(), "Total energy: 2.0 PJ\n") {
+		t.Errorf("Expected the total energy in the summary, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	printSummary(&buf, quakes, len(quakes), options{})
+	if strings.Contains(buf.String(), "energy") {
+		t.Errorf("Expected no energy without -energy, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintQuakesRegion(t *testing.T) {
+	opts, err := parseFlags([]string{"-region", "california"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	printQuakes(&buf, Metadata{}, nil, 0, opts)
+	expected := "Region california: -124.5,32.5,-114.1,42 (minLon,minLat,maxLon,maxLat)\n"
+	if !strings.HasPrefix(buf.String(), expected) {
+		t.Errorf("Expected the resolved bounds %q, got:\n%s", expected, buf.String())
+	}
+}
+
+func TestPrintEarthquakeInfoGeohash(t *testing.T) {
+	quake := QuakeRecord{Place: "San Francisco"}
+	quake.setCoordinates([]float64{-122.4194, 37.7749, 8})
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, quake, options{location: time.UTC, geohash: true, geohashPrecision: 6})
+	if !strings.Contains(buf.String(), "Coordinates: 37.7749, -122.4194\nGeohash: 9q8yyk\n") {
+		t.Errorf("Expected the geohash after the coordinates, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintEarthquakeInfoBearing(t *testing.T) {
+	quake := newMatchRecord(Feature{Geometry: Geometry{Coordinates: []float64{-118.2437, 34.0522, 10}}},
+		options{near: true, nearLatitude: 37.7749, nearLongitude: -122.4194})
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, quake, options{location: time.UTC, near: true, nearLatitude: 37.7749, nearLongitude: -122.4194, bearing: true})
+	if !strings.Contains(buf.String(), "Distance: 559 km SE of your location\n") {
+		t.Errorf("Expected the distance and bearing, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintEarthquakeInfoStatusAndNetwork(t *testing.T) {
+	earthquakeData, err := decodeEarthquakeData(strings.NewReader(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"mag": 4.1, "status": "reviewed", "net": "ci"}},
+		{"type": "Feature", "properties": {"mag": 4.2}}
+	]}`))
+	if err != nil {
+		t.Fatalf("Failed to decode features: %v", err)
+	}
+
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, newQuakeRecord(earthquakeData.Features[0]), options{location: time.UTC})
+	if !strings.Contains(buf.String(), "Status: reviewed\nNetwork: ci\n") {
+		t.Errorf("Expected the review status and network, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	printEarthquakeInfo(&buf, newQuakeRecord(earthquakeData.Features[1]), options{location: time.UTC})
+	if strings.Contains(buf.String(), "Status:") || strings.Contains(buf.String(), "Network:") {
+		t.Errorf("Expected no status or network lines without them, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// otherRegion collects earthquakes whose place has no usable region.
+const otherRegion = "Other"
+
+// regionFromPlace returns the region a USGS place string ends with, e.g.
+// "Alaska" for "10 km S of Cantwell, Alaska".
+func regionFromPlace(place string) string {
+	region := strings.TrimSpace(place[strings.LastIndex(place, ",")+1:])
+	if region == "" {
+		return otherRegion
+	}
+	return region
+}
+
+// regionGroup is the earthquakes sharing a region, in their original order.
+type regionGroup struct {
+	region string
+	quakes []QuakeRecord
+}
+
+// groupByRegion groups quakes by region, largest groups first and ties in
+// alphabetical order, with "Other" always last.
+func groupByRegion(quakes []QuakeRecord) []regionGroup {
+	index := make(map[string]int)
+	var groups []regionGroup
+
+	for _, quake := range quakes {
+		region := regionFromPlace(quake.Place)
+		i, ok := index[region]
+		if !ok {
+			i = len(groups)
+			index[region] = i
+			groups = append(groups, regionGroup{region: region})
+		}
+		groups[i].quakes = append(groups[i].quakes, quake)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if (a.region == otherRegion) != (b.region == otherRegion) {
+			return b.region == otherRegion
+		}
+		if len(a.quakes) != len(b.quakes) {
+			return len(a.quakes) > len(b.quakes)
+		}
+		return a.region < b.region
+	})
+	return groups
+}
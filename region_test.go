@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestRegionFromPlace(t *testing.T) {
+	tests := []struct {
+		place    string
+		expected string
+	}{
+		{"10 km S of Cantwell, Alaska", "Alaska"},
+		{"5km NNE of Town, Sub, Japan ", "Japan"},
+		{"Fiji region", "Fiji region"},
+		{"", otherRegion},
+		{"Somewhere,", otherRegion},
+	}
+
+	for _, tt := range tests {
+		if got := regionFromPlace(tt.place); got != tt.expected {
+			t.Errorf("regionFromPlace(%q) = %q, expected %q", tt.place, got, tt.expected)
+		}
+	}
+}
+
+func TestGroupByRegion(t *testing.T) {
+	quakes := []QuakeRecord{
+		{Place: "A, Japan"},
+		{Place: ""},
+		{Place: "B, Alaska"},
+		{Place: "C, Japan"},
+		{Place: "D, Chile"},
+	}
+
+	groups := groupByRegion(quakes)
+
+	expected := []struct {
+		region string
+		count  int
+	}{{"Japan", 2}, {"Alaska", 1}, {"Chile", 1}, {otherRegion, 1}}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, e := range expected {
+		if groups[i].region != e.region || len(groups[i].quakes) != e.count {
+			t.Errorf("Group %d: expected %s (%d), got %s (%d)", i, e.region, e.count, groups[i].region, len(groups[i].quakes))
+		}
+	}
+	if groups[0].quakes[1].Place != "C, Japan" {
+		t.Errorf("Expected feed order within a group, got %+v", groups[0].quakes)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// rssChannelLink is the page the -format rss channel links to.
+const rssChannelLink = "https://earthquake.usgs.gov/earthquakes/map/"
+
+// Minimal RSS 2.0 schema used by -format rss.
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	PubDate     string  `xml:"pubDate"`
+	GUID        rssGUID `xml:"guid"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// writeRSS writes an RSS 2.0 feed with one item per earthquake, titled by
+// magnitude and place and dated at its origin time. encoding/xml escapes the
+// place names.
+func writeRSS(w io.Writer, quakes []QuakeRecord) error {
+	doc := rssDocument{Version: "2.0", Channel: rssChannel{
+		Title:       "Earthquakes",
+		Link:        rssChannelLink,
+		Description: "Earthquakes reported by the USGS, selected by eqk",
+		Items:       make([]rssItem, 0, len(quakes)),
+	}}
+
+	for _, quake := range quakes {
+		item := rssItem{
+			Title:   "Earthquake, " + quake.Place,
+			Link:    quake.URL,
+			PubDate: quake.Time.UTC().Format(time.RFC1123Z),
+			GUID:    rssGUID{Value: quake.ID},
+		}
+		if !quake.unknownMag {
+			item.Title = fmt.Sprintf("M%.1f, %s", quake.Mag, quake.Place)
+		}
+		if quake.hasDepth {
+			item.Description = fmt.Sprintf("Depth: %.1f km", quake.Depth)
+		}
+		if item.GUID.Value == "" {
+			// Features without an ID are identified by their event page
+			item.GUID = rssGUID{IsPermaLink: true, Value: quake.URL}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
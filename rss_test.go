@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestWriteRSS(t *testing.T) {
+	quakes := []QuakeRecord{
+		{
+			ID:    "us1000abcd",
+			Place: "Coast & <Sea>",
+			Mag:   6.4,
+			Time:  time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+			URL:   "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+		},
+		{Place: "Unknown", unknownMag: true, URL: "https://example.com/event"},
+	}
+	quakes[0].setCoordinates([]float64{142.1, 38.3, 10})
+
+	var buf bytes.Buffer
+	if err := writeRSS(&buf, quakes); err != nil {
+		t.Fatalf("writeRSS() returned an error: %v", err)
+	}
+
+	var doc rssDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("writeRSS() produced invalid XML: %v\n%s", err, buf.String())
+	}
+	if doc.Version != "2.0" || doc.Channel.Link != rssChannelLink {
+		t.Errorf("Unexpected channel %+v", doc)
+	}
+
+	items := doc.Channel.Items
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	expected := rssItem{
+		Title:       "M6.4, Coast & <Sea>",
+		Link:        "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+		Description: "Depth: 10.0 km",
+		PubDate:     "Tue, 02 Jan 2024 15:04:05 +0000",
+		GUID:        rssGUID{Value: "us1000abcd"},
+	}
+	if items[0] != expected {
+		t.Errorf("Expected item %+v, got %+v", expected, items[0])
+	}
+	if items[1].Title != "Earthquake, Unknown" || items[1].GUID != (rssGUID{IsPermaLink: true, Value: "https://example.com/event"}) {
+		t.Errorf("Unexpected item for an unknown magnitude: %+v", items[1])
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serveCacheTTL is how long -serve shares one upstream fetch of a feed; USGS
+// regenerates its summary feeds about once a minute.
+const serveCacheTTL = time.Minute
+
+// serveParams maps the /quakes query parameters to the flags they stand for.
+// Only filtering flags are exposed; nothing that reads or writes files.
+var serveParams = map[string]bool{
+	"min": true, "max": true, "feed": true, "days": true, "class": true,
+	"near": true, "radius": true, "bbox": true, "region": true,
+	"min-depth": true, "max-depth": true,
+	"min-sig": true, "min-felt": true, "min-alert": true, "status": true, "net": true, "tsunami-only": true,
+	"place": true, "place-regex": true, "filter": true,
+	"since": true, "until": true, "updated-since": true,
+	"sort": true, "limit": true,
+}
+
+// serveBoolParams are the serveParams that stand for boolean flags, which may
+// be given without a value, as in ?tsunami-only.
+var serveBoolParams = map[string]bool{"tsunami-only": true}
+
+// quakeServer answers /quakes requests, sharing feed fetches between
+// concurrent requests.
+type quakeServer struct {
+	// base supplies the fetch settings (timeout, retries and cache TTL) and
+	// the feeds used when a request does not name one
+	base options
+
+	mu      sync.Mutex
+	fetches map[string]*sharedFetch
+}
+
+// sharedFetch is one upstream fetch; done is closed once data and err are set.
+type sharedFetch struct {
+	done    chan struct{}
+	started time.Time
+	data    Earthquake
+	err     error
+}
+
+// serveQuakes runs the -serve HTTP API until ctx is canceled.
+func serveQuakes(ctx context.Context, opts options) error {
+	mux := http.NewServeMux()
+	mux.Handle("/quakes", &quakeServer{base: opts, fetches: make(map[string]*sharedFetch)})
+	server := &http.Server{Addr: opts.serveAddr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("Serving earthquakes", "addr", opts.serveAddr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// queryArgs turns query parameters into command-line arguments for
+// parseArgs, rejecting any parameter that is not in serveParams. An empty
+// boolean parameter is true, as its flag would be.
+func queryArgs(query map[string][]string) ([]string, error) {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		if !serveParams[name] {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		for _, value := range query[name] {
+			if value == "" && serveBoolParams[name] {
+				value = "true"
+			}
+			args = append(args, "-"+name+"="+value)
+		}
+	}
+	return args, nil
+}
+
+func (s *quakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("only GET is supported"))
+		return
+	}
+
+	args, err := queryArgs(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts, err := parseArgs(args, io.Discard, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	warnTimezone(opts)
+	opts.timeout, opts.retries, opts.cacheTTL = s.base.timeout, s.base.retries, s.base.cacheTTL
+	if query := r.URL.Query(); !query.Has("feed") && !query.Has("days") && !query.Has("class") {
+		// Default to the feeds eqk was started with
+		opts.url, opts.feedURLs = s.base.url, s.base.feedURLs
+	}
+
+	earthquakeData, err := s.load(r.Context(), opts)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	quakes := collectQuakes(earthquakeData, opts)
+	if opts.limit > 0 && len(quakes) > opts.limit {
+		quakes = quakes[:opts.limit]
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, quakes); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
+// load returns the feeds selected by opts. A fetch started less than
+// serveCacheTTL ago is shared, so concurrent requests for the same feeds
+// cause a single upstream request; failed fetches are retried on the next
+// request.
+func (s *quakeServer) load(ctx context.Context, opts options) (Earthquake, error) {
+	key := strings.Join(opts.feedURLs, ",")
+	if key == "" {
+		key = opts.url
+	}
+
+	s.mu.Lock()
+	fetch, ok := s.fetches[key]
+	if !ok || time.Since(fetch.started) >= serveCacheTTL || fetch.failed() {
+		fetch = &sharedFetch{done: make(chan struct{}), started: time.Now()}
+		s.fetches[key] = fetch
+
+		// The fetch outlives the request that started it, since others may
+		// be waiting on it
+		go func() {
+			defer close(fetch.done)
+			fetch.data, _, fetch.err = loadEarthquakeData(context.Background(), opts)
+		}()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.data, fetch.err
+	case <-ctx.Done():
+		return Earthquake{}, ctx.Err()
+	}
+}
+
+// failed reports whether the fetch has finished with an error.
+func (f *sharedFetch) failed() bool {
+	select {
+	case <-f.done:
+		return f.err != nil
+	default:
+		return false
+	}
+}
+
+// writeError answers with a JSON error object.
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
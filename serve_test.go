@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQuakeServer(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	var calls atomic.Int32
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"type": "FeatureCollection", "features": [
+			{"id": "a", "properties": {"mag": 3.1, "place": "Nevada", "time": 1700000000000}, "geometry": {"type": "Point", "coordinates": [-117, 38, 5]}},
+			{"id": "b", "properties": {"mag": 6.2, "place": "Japan", "time": 1700000100000, "tsunami": 1}, "geometry": {"type": "Point", "coordinates": [142, 38, 30]}}
+		]}`))
+	}))
+	defer feed.Close()
+
+	base, err := parseFlags([]string{"-url", feed.URL})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	server := &quakeServer{base: base, fetches: make(map[string]*sharedFetch)}
+
+	// Requests take their options from the query alone
+	originalConfigPath := configPath
+	defer func() { configPath = originalConfigPath }()
+	config := writeConfig(t, "min: 5\n")
+	configPath = func() (string, error) { return config, nil }
+	t.Setenv("EQK_MIN", "5")
+
+	tests := []struct {
+		query  string
+		status int
+		places []string
+	}{
+		{"", http.StatusOK, []string{"Nevada", "Japan"}},
+		{"?min=5", http.StatusOK, []string{"Japan"}},
+		{"?place=nev", http.StatusOK, []string{"Nevada"}},
+		{"?sort=mag&limit=1", http.StatusOK, []string{"Japan"}},
+		{"?tsunami-only", http.StatusOK, []string{"Japan"}},
+		{"?tsunami-only=false", http.StatusOK, []string{"Nevada", "Japan"}},
+		{"?min=loud", http.StatusBadRequest, nil},
+		{"?file=/etc/passwd", http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest("GET", "/quakes"+test.query, nil))
+		if recorder.Code != test.status {
+			t.Errorf("GET /quakes%s: expected status %d, got %d: %s", test.query, test.status, recorder.Code, recorder.Body)
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+
+		var quakes []QuakeRecord
+		if err := json.Unmarshal(recorder.Body.Bytes(), &quakes); err != nil {
+			t.Fatalf("GET /quakes%s: invalid JSON: %v", test.query, err)
+		}
+		var places []string
+		for _, quake := range quakes {
+			places = append(places, quake.Place)
+		}
+		if len(places) != len(test.places) {
+			t.Errorf("GET /quakes%s: expected %v, got %v", test.query, test.places, places)
+			continue
+		}
+		for i := range places {
+			if places[i] != test.places[i] {
+				t.Errorf("GET /quakes%s: expected %v, got %v", test.query, test.places, places)
+				break
+			}
+		}
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected requests to share one upstream fetch, got %d", calls.Load())
+	}
+}
+
+func TestQuakeServerSharesConcurrentFetches(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	var calls atomic.Int32
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+	}))
+	defer feed.Close()
+
+	base, err := parseFlags([]string{"-url", feed.URL})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	server := &quakeServer{base: base, fetches: make(map[string]*sharedFetch)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest("GET", "/quakes?min=1", nil))
+			if recorder.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d: %s", recorder.Code, recorder.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected concurrent requests to share one upstream fetch, got %d", calls.Load())
+	}
+}
+
+func TestQuakeServerRejectsPost(t *testing.T) {
+	server := &quakeServer{fetches: make(map[string]*sharedFetch)}
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest("POST", "/quakes", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", recorder.Code)
+	}
+}
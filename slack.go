@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Slack attachment colors, matching the terminal severity colors.
+const (
+	slackGreen  = "#2eb67d"
+	slackYellow = "#ecb22e"
+	slackOrange = "#f2811d"
+	slackRed    = "#e01e5a"
+	slackGray   = "#868686"
+)
+
+// slackMessage is a Slack incoming-webhook payload.
+type slackMessage struct {
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// slackAttachment is a legacy Slack message attachment, which can carry a
+// colored side bar.
+type slackAttachment struct {
+	Fallback  string       `json:"fallback"`
+	Color     string       `json:"color"`
+	Title     string       `json:"title"`
+	TitleLink string       `json:"title_link,omitempty"`
+	Fields    []slackField `json:"fields"`
+	Timestamp int64        `json:"ts"`
+}
+
+// slackField is one labeled value of a slackAttachment.
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// slackSeverityColors is the attachment color for each severity.
+var slackSeverityColors = [...]string{
+	severityMinor:    slackGreen,
+	severityModerate: slackYellow,
+	severityStrong:   slackOrange,
+	severityMajor:    slackRed,
+}
+
+// slackColor picks the attachment color for a magnitude's severity.
+func slackColor(mag float64) string {
+	return slackSeverityColors[magnitudeSeverity(mag)]
+}
+
+// newSlackMessage formats quake as a Slack message linking to its USGS
+// event page. The fallback text, shown in notifications, names the place and
+// depth.
+func newSlackMessage(quake QuakeRecord, opts options) slackMessage {
+	title, magnitude, color := "Earthquake, "+quake.Place, "unknown", slackGray
+	if !quake.unknownMag {
+		title = fmt.Sprintf("M%.1f earthquake, %s", quake.Mag, quake.Place)
+		magnitude, color = fmt.Sprintf("%.1f", quake.Mag), slackColor(quake.Mag)
+	}
+	if quake.MagType != "" {
+		magnitude += " (" + quake.MagType + ")"
+	}
+
+	depth := "unknown"
+	if quake.hasDepth {
+		depth = formatLength(quake.Depth, 1, opts.units)
+	}
+
+	return slackMessage{Attachments: []slackAttachment{{
+		Fallback:  fmt.Sprintf("%s, depth %s", title, depth),
+		Color:     color,
+		Title:     title,
+		TitleLink: quake.URL,
+		Fields: []slackField{
+			{Title: "Magnitude", Value: magnitude, Short: true},
+			{Title: "Depth", Value: depth, Short: true},
+			{Title: "Time", Value: quake.Time.In(opts.location).Format(dateFormat)},
+		},
+		Timestamp: quake.Time.Unix(),
+	}}}
+}
+
+// postSlack posts each earthquake of at least the -slack-min magnitude to
+// -slack; with a threshold, unknown magnitudes are not posted. Failed
+// deliveries are logged rather than stopping the watch.
+func postSlack(ctx context.Context, quakes []QuakeRecord, opts options) {
+	if opts.slackURL == "" {
+		return
+	}
+
+	for _, quake := range quakes {
+		if opts.slackMagnitude > 0 && (quake.unknownMag || quake.Mag < opts.slackMagnitude) {
+			continue
+		}
+		if err := postJSON(ctx, opts.slackURL, newSlackMessage(quake, opts)); err != nil {
+			slog.Warn("Slack delivery failed", "id", quake.ID, "err", err)
+		}
+	}
+}
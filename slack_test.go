@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewSlackMessage(t *testing.T) {
+	quake := QuakeRecord{
+		ID:      "us1000abcd",
+		Place:   "10 km S of Somewhere",
+		Mag:     6.1,
+		MagType: "mww",
+		Time:    time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+		URL:     "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+	}
+	quake.setCoordinates([]float64{142.1, 38.3, 10})
+
+	data, err := json.Marshal(newSlackMessage(quake, options{location: time.UTC}))
+	if err != nil {
+		t.Fatalf("Failed to marshal the Slack message: %v", err)
+	}
+
+	expected := `{"attachments":[{` +
+		`"fallback":"M6.1 earthquake, 10 km S of Somewhere, depth 10.0 km",` +
+		`"color":"#f2811d",` +
+		`"title":"M6.1 earthquake, 10 km S of Somewhere",` +
+		`"title_link":"https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",` +
+		`"fields":[` +
+		`{"title":"Magnitude","value":"6.1 (mww)","short":true},` +
+		`{"title":"Depth","value":"10.0 km","short":true},` +
+		`{"title":"Time","value":"2024-01-02 12:00:00 UTC","short":false}],` +
+		`"ts":1704196800}]}`
+	if string(data) != expected {
+		t.Errorf("Unexpected Slack payload:\nexpected %s\ngot      %s", expected, data)
+	}
+}
+
+func TestSlackColor(t *testing.T) {
+	tests := []struct {
+		mag      float64
+		expected string
+	}{
+		{2.9, slackGreen},
+		{3, slackYellow},
+		{5.5, slackOrange},
+		{7.2, slackRed},
+	}
+
+	for _, test := range tests {
+		if got := slackColor(test.mag); got != test.expected {
+			t.Errorf("slackColor(%v): expected %s, got %s", test.mag, test.expected, got)
+		}
+	}
+
+	message := newSlackMessage(QuakeRecord{Place: "Nowhere", unknownMag: true}, options{location: time.UTC})
+	if attachment := message.Attachments[0]; attachment.Color != slackGray || attachment.Fallback != "Earthquake, Nowhere, depth unknown" {
+		t.Errorf("Unexpected attachment for an unknown magnitude: %+v", attachment)
+	}
+}
+
+func TestPostSlackMinimum(t *testing.T) {
+	var titles []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var message slackMessage
+		if err := json.Unmarshal(body, &message); err != nil {
+			t.Errorf("Invalid Slack payload %q: %v", body, err)
+		}
+		titles = append(titles, message.Attachments[0].Title)
+	}))
+	defer server.Close()
+
+	quakes := []QuakeRecord{
+		{ID: "a", Place: "Weak", Mag: 3.2},
+		{ID: "b", Place: "Strong", Mag: 5.4},
+		{ID: "c", Place: "Unknown", unknownMag: true},
+	}
+
+	opts := options{slackURL: server.URL, location: time.UTC}
+	postSlack(context.Background(), quakes, opts)
+	if len(titles) != 3 {
+		t.Errorf("Expected every earthquake without -slack-min, got %v", titles)
+	}
+
+	titles = nil
+	opts.slackMagnitude = 5
+	postSlack(context.Background(), quakes, opts)
+	if len(titles) != 1 || titles[0] != "M5.4 earthquake, Strong" {
+		t.Errorf("Expected only the M5.4 earthquake with -slack-min 5, got %v", titles)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "sort"
+
+// Sort keys accepted by the -sort flag. Without -sort, earthquakes are
+// printed in feed order.
+const (
+	sortByMagnitude = "mag"
+	sortByTime      = "time"
+	sortByDepth     = "depth"
+)
+
+// sortKeys lists every supported sort key.
+var sortKeys = []string{sortByMagnitude, sortByTime, sortByDepth}
+
+// quakeLess orders earthquakes for each sort key: strongest (unknown
+// magnitudes last), newest and shallowest (unknown depths last) first
+// respectively.
+var quakeLess = map[string]func(a, b QuakeRecord) bool{
+	sortByMagnitude: func(a, b QuakeRecord) bool { return !a.unknownMag && (b.unknownMag || a.Mag > b.Mag) },
+	sortByTime:      func(a, b QuakeRecord) bool { return a.Time.After(b.Time) },
+	sortByDepth:     func(a, b QuakeRecord) bool { return a.hasDepth && (!b.hasDepth || a.Depth < b.Depth) },
+}
+
+// sortQuakes sorts quakes in place by key. Ties keep their feed order.
+func sortQuakes(quakes []QuakeRecord, key string) {
+	less, ok := quakeLess[key]
+	if !ok {
+		return
+	}
+	sort.SliceStable(quakes, func(i, j int) bool {
+		return less(quakes[i], quakes[j])
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortQuakesByMagnitude(t *testing.T) {
+	quakes := []QuakeRecord{
+		{Place: "A", Mag: 4.5},
+		{Place: "U", unknownMag: true},
+		{Place: "B", Mag: 6.1},
+		{Place: "C", Mag: 4.5},
+		{Place: "D", Mag: 5.0},
+	}
+
+	sortQuakes(quakes, sortByMagnitude)
+
+	// Ties keep feed order, so A stays ahead of C; unknown magnitudes go last
+	expected := []string{"B", "D", "A", "C", "U"}
+	for i, place := range expected {
+		if quakes[i].Place != place {
+			t.Errorf("Position %d: expected %s, got %s", i, place, quakes[i].Place)
+		}
+	}
+}
+
+func TestSortQuakesByDepth(t *testing.T) {
+	quakes := []QuakeRecord{
+		{Place: "A", Depth: 35, hasDepth: true},
+		{Place: "U"},
+		{Place: "B", Depth: 10, hasDepth: true},
+		{Place: "C", Depth: 600, hasDepth: true},
+		{Place: "V"},
+	}
+
+	sortQuakes(quakes, sortByDepth)
+
+	// Unknown depths go last, in feed order
+	expected := []string{"B", "A", "C", "U", "V"}
+	for i, place := range expected {
+		if quakes[i].Place != place {
+			t.Errorf("Position %d: expected %s, got %s", i, place, quakes[i].Place)
+		}
+	}
+}
+
+func TestSortQuakesByTime(t *testing.T) {
+	origin := time.UnixMilli(1633455600000).UTC()
+	quakes := []QuakeRecord{
+		{Place: "A", Time: origin},
+		{Place: "B", Time: origin.Add(2 * time.Hour)},
+		{Place: "C", Time: origin},
+		{Place: "D", Time: origin.Add(time.Hour)},
+	}
+
+	sortQuakes(quakes, sortByTime)
+
+	// Newest first; A and C share a timestamp and keep feed order
+	expected := []string{"B", "D", "A", "C"}
+	for i, place := range expected {
+		if quakes[i].Place != place {
+			t.Errorf("Position %d: expected %s, got %s", i, place, quakes[i].Place)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// watchState is the -state file: the IDs of the earthquakes watch mode has
+// reported, each with its event time so old entries can be pruned.
+type watchState struct {
+	Seen map[string]time.Time `json:"seen"`
+}
+
+// loadSeen reads the seen IDs from a -state file; loaded reports whether
+// there was one. A missing file is an empty state, as on the first run.
+func loadSeen(path string) (seen map[string]time.Time, loaded bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]time.Time), false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var state watchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, false, err
+	}
+	if state.Seen == nil {
+		state.Seen = make(map[string]time.Time)
+	}
+	return state.Seen, true, nil
+}
+
+// saveSeen writes the seen IDs to a -state file. It writes a temporary file
+// and renames it over path, so a run killed midway leaves the old state.
+func saveSeen(path string, seen map[string]time.Time) error {
+	data, err := json.Marshal(watchState{Seen: seen})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// pruneSeen forgets the earthquakes that happened before cutoff. They have
+// dropped out of the feed, so they cannot be reported again.
+func pruneSeen(seen map[string]time.Time, cutoff time.Time) {
+	for id, t := range seen {
+		if t.Before(cutoff) {
+			delete(seen, id)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSeenState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.json")
+
+	seen, loaded, err := loadSeen(path)
+	if err != nil {
+		t.Fatalf("loadSeen() returned an error for a missing file: %v", err)
+	}
+	if len(seen) != 0 || loaded {
+		t.Errorf("Expected an empty state that was not loaded, got %v, %v", seen, loaded)
+	}
+
+	origin := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	seen["old"] = origin.Add(-40 * 24 * time.Hour)
+	seen["new"] = origin
+	if err := saveSeen(path, seen); err != nil {
+		t.Fatalf("saveSeen() returned an error: %v", err)
+	}
+
+	saved, loaded, err := loadSeen(path)
+	if err != nil {
+		t.Fatalf("loadSeen() returned an error: %v", err)
+	}
+	if len(saved) != 2 || !saved["new"].Equal(origin) || !loaded {
+		t.Errorf("Expected the saved state back, got %v, %v", saved, loaded)
+	}
+
+	pruneSeen(saved, origin.Add(-feedWindow("all_month")))
+	if _, ok := saved["old"]; ok || len(saved) != 1 {
+		t.Errorf("Expected only events inside the feed window to remain, got %v", saved)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadSeen(path); err == nil {
+		t.Error("loadSeen() expected an error for a corrupt file, got nil")
+	}
+}
+
+func TestFeedWindow(t *testing.T) {
+	tests := []struct {
+		feed     string
+		expected time.Duration
+	}{
+		{"4.5_hour", time.Hour},
+		{"4.5_day,significant_week", 7 * 24 * time.Hour},
+		{"", 30 * 24 * time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := feedWindow(test.feed); got != test.expected {
+			t.Errorf("feedWindow(%q): expected %s, got %s", test.feed, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// quakeStats aggregates a set of matched earthquakes.
+type quakeStats struct {
+	// strongest and the magnitude averages are only set when hasMagnitude
+	// is true; unknown magnitudes are left out
+	hasMagnitude    bool
+	strongest       QuakeRecord
+	meanMagnitude   float64
+	medianMagnitude float64
+	// totalEnergy is the estimated energy in joules of every known magnitude
+	totalEnergy float64
+
+	// shallowest and deepest are only set when hasDepth is true
+	hasDepth   bool
+	shallowest QuakeRecord
+	deepest    QuakeRecord
+}
+
+// computeStats summarizes quakes; ok is false when there are none.
+func computeStats(quakes []QuakeRecord) (stats quakeStats, ok bool) {
+	if len(quakes) == 0 {
+		return quakeStats{}, false
+	}
+
+	magnitudes := make([]float64, 0, len(quakes))
+	sum := 0.0
+
+	for _, quake := range quakes {
+		if !quake.unknownMag {
+			magnitudes = append(magnitudes, quake.Mag)
+			sum += quake.Mag
+			stats.totalEnergy += energyJoules(quake.Mag)
+
+			if !stats.hasMagnitude || quake.Mag > stats.strongest.Mag {
+				stats.strongest = quake
+			}
+			stats.hasMagnitude = true
+		}
+
+		if quake.hasDepth {
+			if !stats.hasDepth || quake.Depth < stats.shallowest.Depth {
+				stats.shallowest = quake
+			}
+			if !stats.hasDepth || quake.Depth > stats.deepest.Depth {
+				stats.deepest = quake
+			}
+			stats.hasDepth = true
+		}
+	}
+
+	if !stats.hasMagnitude {
+		return stats, true
+	}
+
+	stats.meanMagnitude = sum / float64(len(magnitudes))
+
+	sort.Float64s(magnitudes)
+	middle := len(magnitudes) / 2
+	if len(magnitudes)%2 == 0 {
+		stats.medianMagnitude = (magnitudes[middle-1] + magnitudes[middle]) / 2
+	} else {
+		stats.medianMagnitude = magnitudes[middle]
+	}
+
+	return stats, true
+}
+
+// histogramBucket counts earthquakes with floor(magnitude) == low.
+type histogramBucket struct {
+	low   int
+	count int
+}
+
+// magnitudeHistogram buckets quakes by whole magnitude, including empty
+// buckets between the weakest and strongest so gaps stay visible. Unknown
+// magnitudes are left out.
+func magnitudeHistogram(quakes []QuakeRecord) []histogramBucket {
+	counts := make(map[int]int)
+	lowest, highest := math.MaxInt, math.MinInt
+	for _, quake := range quakes {
+		if quake.unknownMag {
+			continue
+		}
+		bucket := int(math.Floor(quake.Mag))
+		counts[bucket]++
+		if bucket < lowest {
+			lowest = bucket
+		}
+		if bucket > highest {
+			highest = bucket
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	buckets := make([]histogramBucket, 0, highest-lowest+1)
+	for low := lowest; low <= highest; low++ {
+		buckets = append(buckets, histogramBucket{low: low, count: counts[low]})
+	}
+	return buckets
+}
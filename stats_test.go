@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestComputeStats(t *testing.T) {
+	quakes := []QuakeRecord{
+		{Place: "A", Mag: 4.0},
+		{Place: "B", Mag: 6.5},
+		{Place: "C", Mag: 5.0},
+		{Place: "D", Mag: 4.5},
+		// E has no magnitude and must not count as magnitude 0
+		{Place: "E", unknownMag: true},
+	}
+	quakes[0].setCoordinates([]float64{0, 0, 35})
+	quakes[1].setCoordinates([]float64{0, 0, 10})
+	quakes[2].setCoordinates([]float64{0, 0, 600})
+	// D has no depth and must not count as 0 km deep
+	quakes[3].setCoordinates([]float64{0, 0})
+
+	stats, ok := computeStats(quakes)
+	if !ok {
+		t.Fatal("computeStats() returned ok = false")
+	}
+
+	if stats.strongest.Place != "B" {
+		t.Errorf("Expected strongest B, got %s", stats.strongest.Place)
+	}
+	if stats.meanMagnitude != 5.0 {
+		t.Errorf("Expected mean 5.0, got %v", stats.meanMagnitude)
+	}
+	if stats.medianMagnitude != 4.75 {
+		t.Errorf("Expected median 4.75, got %v", stats.medianMagnitude)
+	}
+	if stats.shallowest.Place != "B" || stats.deepest.Place != "C" {
+		t.Errorf("Expected shallowest B and deepest C, got %s and %s", stats.shallowest.Place, stats.deepest.Place)
+	}
+
+	if stats, _ := computeStats([]QuakeRecord{{unknownMag: true}}); stats.hasMagnitude {
+		t.Error("Expected no magnitude statistics without known magnitudes")
+	}
+
+	if _, ok := computeStats(nil); ok {
+		t.Error("computeStats(nil) expected ok = false")
+	}
+}
+
+func TestMagnitudeHistogram(t *testing.T) {
+	quakes := []QuakeRecord{{Mag: 2.5}, {Mag: 2.0}, {Mag: 5.9}, {Mag: 2.99}}
+
+	buckets := magnitudeHistogram(quakes)
+
+	expected := []histogramBucket{{2, 3}, {3, 0}, {4, 0}, {5, 1}}
+	if len(buckets) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %d: %+v", len(expected), len(buckets), buckets)
+	}
+	for i := range expected {
+		if buckets[i] != expected[i] {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, expected[i], buckets[i])
+		}
+	}
+}
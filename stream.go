@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// canStream reports whether the output can be written one earthquake at a
+// time as the feed is decoded, without holding every feature in memory.
+// Sorting, the text report's header and statistics, and the document formats
+// need every match first, as do merging several feeds and -db.
+func canStream(opts options) bool {
+	if opts.sort != "" || len(opts.feedURLs) > 1 || opts.dbPath != "" {
+		return false
+	}
+	return opts.countOnly || opts.format == formatCSV || opts.format == formatJSONL
+}
+
+// streamQuakes is listQuakes for the outputs canStream accepts: each feature
+// is filtered and written as soon as it is decoded, in a single pass. Only
+// the IDs already seen are kept, so a repeated ID is skipped as dropRepeats
+// does for the other outputs.
+func streamQuakes(ctx context.Context, w io.Writer, opts options) (int, error) {
+	r, err := openEarthquakeData(ctx, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch earthquake data: %w", err)
+	}
+	defer r.Close()
+
+	var write func(QuakeRecord) error
+	var csvWriter *csv.Writer
+	switch {
+	case opts.countOnly:
+		write = func(QuakeRecord) error { return nil }
+	case opts.format == formatCSV:
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(columnHeaders(opts.columns)); err != nil {
+			return 0, fmt.Errorf("failed to write output: %w", err)
+		}
+		write = func(quake QuakeRecord) error { return csvWriter.Write(columnValues(quake, opts.columns)) }
+	default:
+		encoder := json.NewEncoder(w)
+		write = func(quake QuakeRecord) error { return encoder.Encode(quake) }
+	}
+
+	count := 0
+	seen := make(map[string]bool)
+	var writeErr error
+	header, err := decodeFeatures(r, func(feature Feature) error {
+		if ok, err := acceptFeature(feature, opts.strict); !ok {
+			return err
+		}
+		if feature.ID != "" {
+			if seen[feature.ID] {
+				return nil
+			}
+			seen[feature.ID] = true
+		}
+
+		quake := newMatchRecord(feature, opts)
+		if !opts.matches(quake) {
+			return nil
+		}
+		count++
+		if opts.limit > 0 && count > opts.limit {
+			// Keep counting every match, as listQuakes does
+			return nil
+		}
+
+		if writeErr = write(quake); writeErr != nil {
+			return writeErr
+		}
+		if !opts.countOnly {
+			notifyQuakes([]QuakeRecord{quake}, opts)
+		}
+		return nil
+	})
+	if writeErr != nil {
+		return 0, fmt.Errorf("failed to write output: %w", writeErr)
+	}
+	if err == nil {
+		// The type may follow the features, so it is only known now
+		err = checkCollection(header)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch earthquake data: %w", err)
+	}
+
+	if opts.countOnly {
+		_, err = fmt.Fprintln(w, count)
+	} else if csvWriter != nil {
+		csvWriter.Flush()
+		err = csvWriter.Error()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write output: %w", err)
+	}
+	return count, nil
+}
+
+// openEarthquakeData opens the single feed selected by opts for streaming:
+// the -file path, stdin, or the fetched feed, read from the response as it
+// arrives. -debug dumps the whole body before it is decoded, so it is then
+// read into memory first.
+func openEarthquakeData(ctx context.Context, opts options) (io.ReadCloser, error) {
+	switch opts.inputPath {
+	case "":
+		if debugOutput == nil {
+			return openCached(ctx, opts.url, opts)
+		}
+		body, _, err := fetchCached(ctx, opts.url, opts)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(body)), nil
+	case stdinPath:
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(opts.inputPath)
+}
+
+// decodeFeatures decodes a GeoJSON FeatureCollection token by token, calling
+// fn with each feature as it is read instead of collecting them. It returns
+// the collection's other members, with Features left nil. An error from fn
+// stops the decoding and is returned.
+func decodeFeatures(r io.Reader, fn func(Feature) error) (Earthquake, error) {
+	decoder := json.NewDecoder(r)
+	var header Earthquake
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return Earthquake{}, err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return Earthquake{}, err
+		}
+
+		switch token {
+		case "type":
+			err = decoder.Decode(&header.Type)
+		case "metadata":
+			err = decoder.Decode(&header.Meta)
+		case "features":
+			err = decodeFeatureArray(decoder, fn)
+		default:
+			var skipped json.RawMessage
+			err = decoder.Decode(&skipped)
+		}
+		if err != nil {
+			return Earthquake{}, err
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return Earthquake{}, err
+	}
+
+	return header, nil
+}
+
+// decodeFeatureArray reads the "features" array for decodeFeatures; null is
+// treated as empty.
+func decodeFeatureArray(decoder *json.Decoder, fn func(Feature) error) error {
+	token, err := decoder.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected features array, got %v", token)
+	}
+
+	for decoder.More() {
+		var feature Feature
+		if err := decoder.Decode(&feature); err != nil {
+			return err
+		}
+		if err := fn(feature); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+// expectDelim reads the next token and checks that it is delim.
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const streamFeed = `{
+	"type": "FeatureCollection",
+	"features": [
+		{"id": "a", "properties": {"mag": 4.5, "place": "First", "time": 1700000000000, "updated": 1700000000000}, "geometry": {"type": "Point", "coordinates": [-117, 38, 5]}},
+		{"id": "b", "properties": {"mag": 2.1, "place": "Weak", "time": 1700000100000}, "geometry": {"type": "Point", "coordinates": [-118, 36, 3]}},
+		{"id": "a", "properties": {"mag": 4.7, "place": "First, revised", "time": 1700000000000, "updated": 1700000500000}, "geometry": {"type": "Point", "coordinates": [-117, 38, 5]}},
+		{"id": "c", "properties": {"mag": null, "place": "Unknown", "time": 1700000200000}, "geometry": {"type": "Point", "coordinates": [140, 36, 10]}},
+		{"id": "d", "properties": {"mag": 6.0, "place": "Strong, Japan", "time": 1700000300000}, "geometry": {"type": "Point", "coordinates": [142, 38, 30]}}
+	],
+	"metadata": {"generated": 1700000400000, "count": 5},
+	"bbox": [-118, 36, 3, 142, 38, 30]
+}`
+
+func TestDecodeFeatures(t *testing.T) {
+	var ids []string
+	header, err := decodeFeatures(strings.NewReader(streamFeed), func(feature Feature) error {
+		ids = append(ids, feature.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("decodeFeatures() returned an error: %v", err)
+	}
+	if strings.Join(ids, ",") != "a,b,a,c,d" {
+		t.Errorf("Expected every feature in order, got %v", ids)
+	}
+	if header.Type != "FeatureCollection" || header.Meta.Generated != 1700000400000 || header.Features != nil {
+		t.Errorf("Unexpected header %+v", header)
+	}
+
+	if _, err := decodeFeatures(strings.NewReader(`{"features": null}`), func(Feature) error { return nil }); err != nil {
+		t.Errorf("Expected null features to decode as empty, got %v", err)
+	}
+
+	for _, doc := range []string{`[]`, `{"features": {}}`, `{"features": [{"id": 1}]}`, `{"features": [`} {
+		if _, err := decodeFeatures(strings.NewReader(doc), func(Feature) error { return nil }); err == nil {
+			t.Errorf("decodeFeatures(%q) expected an error, got nil", doc)
+		}
+	}
+}
+
+func TestStreamQuakesMatchesFullDecode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	if err := os.WriteFile(path, []byte(streamFeed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"-format", "csv"},
+		{"-format", "jsonl", "-min", "3"},
+		{"-format", "jsonl", "-limit", "1"},
+		{"-q", "-place", "japan"},
+	} {
+		opts, err := parseFlags(append(args, "-file", path))
+		if err != nil {
+			t.Fatalf("parseFlags(%v) returned an error: %v", args, err)
+		}
+		if !canStream(opts) {
+			t.Fatalf("Expected %v to stream", args)
+		}
+
+		var streamed bytes.Buffer
+		count, err := streamQuakes(context.Background(), &streamed, opts)
+		if err != nil {
+			t.Fatalf("streamQuakes(%v) returned an error: %v", args, err)
+		}
+
+		// The full decode path, as used before streaming
+		earthquakeData, _, err := loadEarthquakeData(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		quakes := collectQuakes(earthquakeData, opts)
+		shown := quakes
+		if opts.limit > 0 && len(shown) > opts.limit {
+			shown = shown[:opts.limit]
+		}
+		var full bytes.Buffer
+		switch {
+		case opts.countOnly:
+			fmt.Fprintln(&full, len(quakes))
+		case opts.format == formatCSV:
+			err = writeCSV(&full, shown, opts.columns)
+		default:
+			err = writeJSONL(&full, shown)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if count != len(quakes) {
+			t.Errorf("%v: expected %d matches, got %d", args, len(quakes), count)
+		}
+		if streamed.String() != full.String() {
+			t.Errorf("%v: streamed output differs from the full decode:\nstreamed:\n%s\nfull:\n%s", args, streamed.String(), full.String())
+		}
+	}
+}
+
+func TestStreamQuakesKeepsFirstListing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	if err := os.WriteFile(path, []byte(streamFeed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := parseFlags([]string{"-format", "csv", "-columns", "id,place,mag", "-file", path})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := streamQuakes(context.Background(), &buf, opts); err != nil {
+		t.Fatalf("streamQuakes() returned an error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || lines[1] != "a,First,4.5" {
+		t.Errorf("Expected the first listing of a once, got:\n%s", buf.String())
+	}
+
+	// The full decode keeps the same copy
+	opts.format = formatJSON
+	earthquakeData, _, err := loadEarthquakeData(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quakes := collectQuakes(earthquakeData, opts)
+	if len(quakes) != 4 || quakes[0].Place != "First" {
+		t.Errorf("Expected the full decode to keep the first listing of a, got %+v", quakes)
+	}
+}
+
+func TestStreamQuakesStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.geojson")
+	if err := os.WriteFile(path, []byte(mixedGeometryFeed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := parseFlags([]string{"-q", "-min", "0", "-file", path})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	var buf bytes.Buffer
+	if count, err := streamQuakes(context.Background(), &buf, opts); err != nil || count != 2 {
+		t.Errorf("Expected the polygon to be skipped, got %d (%v)", count, err)
+	}
+
+	opts.strict = true
+	if _, err := streamQuakes(context.Background(), &buf, opts); err == nil {
+		t.Error("Expected -strict to fail on the polygon")
+	}
+
+	headerLast := `{"features": [], "type": "Feature"}`
+	if err := os.WriteFile(path, []byte(headerLast), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := streamQuakes(context.Background(), &buf, opts); err == nil || !strings.Contains(err.Error(), "unexpected GeoJSON type") {
+		t.Errorf("Expected an error for a document that is not a FeatureCollection, got %v", err)
+	}
+}
+
+func TestCanStream(t *testing.T) {
+	tests := []struct {
+		opts     options
+		expected bool
+	}{
+		{options{format: formatCSV}, true},
+		{options{format: formatJSONL}, true},
+		{options{format: formatText, countOnly: true}, true},
+		{options{format: formatText}, false},
+		{options{format: formatJSON}, false},
+		{options{format: formatCSV, sort: sortByMagnitude}, false},
+		{options{format: formatCSV, feedURLs: []string{"a", "b"}}, false},
+	}
+
+	for _, test := range tests {
+		if got := canStream(test.opts); got != test.expected {
+			t.Errorf("canStream(%+v): expected %v, got %v", test.opts, test.expected, got)
+		}
+	}
+}
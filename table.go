@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// tablePlaceWidth is the widest place -format table prints before cutting
+// it short with an ellipsis.
+const tablePlaceWidth = 40
+
+// writeTable writes the earthquakes as a column-aligned plain-text table,
+// with the same field values as -format csv.
+func writeTable(w io.Writer, quakes []QuakeRecord, columns []column) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	io.WriteString(tw, strings.Join(columnTitles(columns), "\t")+"\n")
+	for _, quake := range quakes {
+		values := columnValues(quake, columns)
+		for i, c := range columns {
+			// Tabs and newlines would break the alignment
+			values[i] = strings.Join(strings.Fields(values[i]), " ")
+			if c.name == "place" {
+				values[i] = truncate(values[i], tablePlaceWidth)
+			}
+		}
+		io.WriteString(tw, strings.Join(values, "\t")+"\n")
+	}
+
+	return tw.Flush()
+}
+
+// truncate shortens s to at most width characters, ending it with an
+// ellipsis when anything was cut.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteTable(t *testing.T) {
+	a := QuakeRecord{Place: "10 km S of Somewhere, CA", Mag: 4.5, Time: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)}
+	a.setCoordinates([]float64{-117.5, 35.25, 8.1})
+	b := QuakeRecord{Place: "A very long place name that goes on\tand on, Alaska", Mag: 6.25, Time: time.Date(2024, 1, 3, 1, 2, 3, 0, time.UTC)}
+	b.setCoordinates([]float64{-150, 61, 120})
+
+	var buf bytes.Buffer
+	if err := writeTable(&buf, []QuakeRecord{a, b}, mustParseColumns(t, defaultTableColumns)); err != nil {
+		t.Fatalf("writeTable() returned an error: %v", err)
+	}
+
+	expected := "" +
+		"Place                                     Mag   Depth  Time\n" +
+		"10 km S of Somewhere, CA                  4.5   8.1    2024-01-02T12:00:00Z\n" +
+		"A very long place name that goes on and…  6.25  120    2024-01-03T01:02:03Z\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected table:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"São Paulo, Brazil", 9, "São Paul…"},
+	}
+
+	for _, test := range tests {
+		if got := truncate(test.s, test.width); got != test.expected {
+			t.Errorf("truncate(%q, %d): expected %q, got %q", test.s, test.width, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// formatTemplate is the output format selected by -template. It is not a
+// -format value since it needs the template file.
+const formatTemplate = "template"
+
+// loadTemplate parses the -template file. The template is executed once per
+// earthquake with its QuakeRecord, so .Mag, .Place, .Time, .Longitude,
+// .Latitude, .Depth and the other record fields are available.
+func loadTemplate(path string, location *time.Location) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := template.FuncMap{
+		// formatTime renders a time in the -tz zone, e.g.
+		// {{formatTime "2006-01-02 15:04" .Time}}
+		"formatTime": func(layout string, t time.Time) string {
+			return t.In(location).Format(layout)
+		},
+		// mapsURL links the epicenter, {{mapsURL .Latitude .Longitude}}, and
+		// is empty when the feed gave none
+		"mapsURL": func(latitude, longitude any) string {
+			lat, hasLatitude := latitude.(float64)
+			lon, hasLongitude := longitude.(float64)
+			if !hasLatitude || !hasLongitude {
+				return ""
+			}
+			return mapsURL(lat, lon)
+		},
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(funcs).Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// templateQuake is what a -template sees of an earthquake: the QuakeRecord
+// fields, except that .Mag, .Longitude, .Latitude and .Depth are nil rather
+// than 0 when the feed did not give them, as they are null in -format json,
+// so {{with .Depth}} can leave them out.
+type templateQuake struct {
+	QuakeRecord
+	Mag       any
+	Longitude any
+	Latitude  any
+	Depth     any
+}
+
+// writeTemplate executes the template for each earthquake in turn.
+func writeTemplate(w io.Writer, tmpl *template.Template, quakes []QuakeRecord) error {
+	for _, quake := range quakes {
+		data := templateQuake{QuakeRecord: quake}
+		if !quake.unknownMag {
+			data.Mag = quake.Mag
+		}
+		if quake.hasLocation {
+			data.Longitude, data.Latitude = quake.Longitude, quake.Latitude
+		}
+		if quake.hasDepth {
+			data.Depth = quake.Depth
+		}
+		if err := tmpl.Execute(w, data); err != nil {
+			return fmt.Errorf("executing template: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quake.tmpl")
+	text := `M{{.Mag}} {{.Place}} at {{formatTime "2006-01-02 15:04 MST" .Time}} ({{.Latitude}}, {{.Longitude}}, {{.Depth}} km)` + "\n"
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	location, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	tmpl, err := loadTemplate(path, location)
+	if err != nil {
+		t.Fatalf("loadTemplate() returned an error: %v", err)
+	}
+
+	quakes := []QuakeRecord{
+		{Place: "Location 1", Mag: 6.5, Time: time.UnixMilli(1633455600000).UTC()},
+		{Place: "Location 2", Mag: 4.1, Time: time.UnixMilli(1633455600000).UTC()},
+	}
+	quakes[0].setCoordinates([]float64{142.1, 38.3, 10})
+
+	var buf bytes.Buffer
+	if err := writeTemplate(&buf, tmpl, quakes); err != nil {
+		t.Fatalf("writeTemplate() returned an error: %v", err)
+	}
+
+	expected := "M6.5 Location 1 at 2021-10-06 02:40 JST (38.3, 142.1, 10 km)\n" +
+		"M4.1 Location 2 at 2021-10-06 02:40 JST (<no value>, <no value>, <no value> km)\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteTemplateUnknownMagnitude(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quake.tmpl")
+	if err := os.WriteFile(path, []byte(`{{with .Mag}}M{{printf "%.1f" .}} {{end}}{{.Place}}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := loadTemplate(path, time.UTC)
+	if err != nil {
+		t.Fatalf("loadTemplate() returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTemplate(&buf, tmpl, []QuakeRecord{{Place: "Known", Mag: 4.5}, {Place: "Unknown", unknownMag: true}}); err != nil {
+		t.Fatalf("writeTemplate() returned an error: %v", err)
+	}
+	if expected := "M4.5 Known\nUnknown\n"; buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteTemplateMissingDepth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quake.tmpl")
+	text := `{{.Place}} {{with .Latitude}}{{.}},{{end}}{{with .Longitude}}{{.}}{{end}}{{with .Depth}} at {{.}} km{{end}} {{mapsURL .Latitude .Longitude}}` + "\n"
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := loadTemplate(path, time.UTC)
+	if err != nil {
+		t.Fatalf("loadTemplate() returned an error: %v", err)
+	}
+
+	quakes := []QuakeRecord{{Place: "Full"}, {Place: "Shallow"}, {Place: "Nowhere"}}
+	quakes[0].setCoordinates([]float64{142.1, 38.3, 10})
+	quakes[1].setCoordinates([]float64{142.1, 38.3})
+
+	var buf bytes.Buffer
+	if err := writeTemplate(&buf, tmpl, quakes); err != nil {
+		t.Fatalf("writeTemplate() returned an error: %v", err)
+	}
+	expected := "Full 38.3,142.1 at 10 km https://www.google.com/maps?q=38.3,142.1\n" +
+		"Shallow 38.3,142.1 https://www.google.com/maps?q=38.3,142.1\n" +
+		"Nowhere  \n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestLoadTemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(path, []byte("{{.Mag"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{path, filepath.Join(dir, "missing.tmpl")} {
+		if _, err := loadTemplate(p, time.UTC); err == nil {
+			t.Errorf("loadTemplate(%q) expected an error, got nil", p)
+		}
+	}
+}
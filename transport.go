@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// newTransport builds the HTTP transport for -insecure, -cacert and -proxy.
+// It returns nil when none is given, leaving http.DefaultTransport, with its
+// full certificate verification and HTTP(S)_PROXY handling, in place.
+func newTransport(opts options) (*http.Transport, error) {
+	if !opts.insecure && opts.caCertPath == "" && opts.proxy == nil {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.insecure}
+	if opts.caCertPath != "" {
+		pem, err := os.ReadFile(opts.caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read -cacert: %w", err)
+		}
+
+		// Trust the extra CA on top of the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in -cacert file %s", opts.caCertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if opts.proxy != nil {
+		// Overrides the HTTP(S)_PROXY and NO_PROXY environment variables
+		transport.Proxy = http.ProxyURL(opts.proxy)
+	}
+	return transport, nil
+}
+
+// parseProxyURL validates a -proxy URL: http, https or socks5, with a host.
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %v", rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: must be an http, https or socks5 URL with a host", rawURL)
+	}
+	return u, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewTransport(t *testing.T) {
+	// Store the original client
+	originalClient := httpClient
+	defer func() { httpClient = originalClient }()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+	}))
+	defer server.Close()
+
+	if transport, err := newTransport(options{}); transport != nil || err != nil {
+		t.Fatalf("Expected the default transport without -insecure or -cacert, got %v, %v", transport, err)
+	}
+	httpClient = &http.Client{}
+	if _, err := fetchFeed(context.Background(), server.URL, defaultTimeout, ""); err == nil {
+		t.Error("Expected the self-signed certificate to be rejected by default")
+	}
+
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(caCert, pem.EncodeToMemory(block), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []options{{caCertPath: caCert}, {insecure: true}} {
+		transport, err := newTransport(opts)
+		if err != nil {
+			t.Fatalf("newTransport(%+v) returned an error: %v", opts, err)
+		}
+		httpClient = &http.Client{Transport: transport}
+		if _, err := fetchFeed(context.Background(), server.URL, defaultTimeout, ""); err != nil {
+			t.Errorf("newTransport(%+v): expected the fetch to succeed, got %v", opts, err)
+		}
+	}
+
+	notPEM := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{notPEM, filepath.Join(dir, "missing.pem")} {
+		if _, err := newTransport(options{caCertPath: path}); err == nil {
+			t.Errorf("newTransport(-cacert %s) expected an error, got nil", path)
+		}
+	}
+}
+
+func TestProxy(t *testing.T) {
+	// Store the original client
+	originalClient := httpClient
+	defer func() { httpClient = originalClient }()
+
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target
+		proxied = r.URL.String()
+		w.Write([]byte(`{"type": "FeatureCollection", "features": []}`))
+	}))
+	defer proxy.Close()
+
+	u, err := parseProxyURL(proxy.URL)
+	if err != nil {
+		t.Fatalf("parseProxyURL() returned an error: %v", err)
+	}
+	transport, err := newTransport(options{proxy: u})
+	if err != nil {
+		t.Fatalf("newTransport() returned an error: %v", err)
+	}
+	httpClient = &http.Client{Transport: transport}
+
+	const target = "http://earthquake.example/feed.geojson"
+	if _, err := fetchFeed(context.Background(), target, defaultTimeout, ""); err != nil {
+		t.Fatalf("fetchFeed() returned an error: %v", err)
+	}
+	if proxied != target {
+		t.Errorf("Expected the request for %s to go through the proxy, got %q", target, proxied)
+	}
+
+	// A proxy that is down is named in the error
+	proxy.Close()
+	_, err = fetchFeed(context.Background(), "https://earthquake.example/feed.geojson", defaultTimeout, "")
+	if err == nil || !strings.Contains(err.Error(), "failed to connect to proxy") {
+		t.Errorf("Expected a proxy connection error, got %v", err)
+	}
+}
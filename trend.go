@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// trendDateFormat labels the days of a trend.
+const trendDateFormat = "2006-01-02"
+
+// sparkLevels are the characters of a sparkline, from a quiet day to the
+// busiest one.
+const sparkLevels = " .:-=+*#%@"
+
+// dayCount is the number of earthquakes on the calendar day starting at day.
+type dayCount struct {
+	day   time.Time
+	count int
+}
+
+// dailyCounts buckets quakes by calendar day in loc, from the day of from to
+// the day of to and widened to take in every earthquake, including the empty
+// days so quiet periods show up.
+func dailyCounts(quakes []QuakeRecord, from, to time.Time, loc *time.Location) []dayCount {
+	counts := make(map[string]int)
+	for _, quake := range quakes {
+		local := quake.Time.In(loc)
+		counts[local.Format(trendDateFormat)]++
+		if local.Before(from) {
+			from = local
+		}
+		if local.After(to) {
+			to = local
+		}
+	}
+
+	// Stepping by calendar date keeps midnight aligned across DST changes
+	from, to = from.In(loc), to.In(loc)
+	var days []dayCount
+	for day := startOfDay(from); !day.After(to); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		days = append(days, dayCount{day: day, count: counts[day.Format(trendDateFormat)]})
+	}
+	return days
+}
+
+// startOfDay returns midnight of t's calendar day in its location.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// trendRange returns the span -trend covers: the feed's window up to now for
+// a live feed, or just the days of the earthquakes in a file.
+func trendRange(quakes []QuakeRecord, opts options) (from, to time.Time, ok bool) {
+	if opts.inputPath == "" {
+		now := timeNow()
+		return now.Add(-feedWindow(opts.feed)), now, true
+	}
+	if len(quakes) == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	from, to = quakes[0].Time, quakes[0].Time
+	return from, to, true
+}
+
+// printTrend prints the number of earthquakes per day, as a bar per day or as
+// a single sparkline.
+func printTrend(w io.Writer, quakes []QuakeRecord, opts options) {
+	from, to, ok := trendRange(quakes, opts)
+	if !ok {
+		return
+	}
+	days := dailyCounts(quakes, from, to, opts.location)
+
+	busiest := days[0]
+	for _, day := range days {
+		if day.count > busiest.count {
+			busiest = day
+		}
+	}
+
+	fmt.Fprintln(w, "-------------------------------------------------------------------")
+	if opts.sparkline {
+		fmt.Fprintf(w, "%s to %s |%s| peak %d on %s\n", days[0].day.Format(trendDateFormat), days[len(days)-1].day.Format(trendDateFormat),
+			sparkline(days, busiest.count), busiest.count, busiest.day.Format(trendDateFormat))
+		return
+	}
+	for _, day := range days {
+		bar := 0
+		if busiest.count > 0 {
+			bar = day.count * histogramWidth / busiest.count
+		}
+		if bar == 0 && day.count > 0 {
+			bar = 1
+		}
+		fmt.Fprintf(w, "%s | %-*s %d\n", day.day.Format(trendDateFormat), histogramWidth, strings.Repeat("#", bar), day.count)
+	}
+}
+
+// sparkline draws one character per day, scaled so a day with largest
+// earthquakes gets the last level and only empty days are blank.
+func sparkline(days []dayCount, largest int) string {
+	var b strings.Builder
+	top := len(sparkLevels) - 1
+	for _, day := range days {
+		level := 0
+		if day.count > 0 {
+			level = (day.count*top + largest - 1) / largest
+		}
+		b.WriteByte(sparkLevels[level])
+	}
+	return b.String()
+}
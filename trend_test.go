@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDailyCounts(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	quakes := []QuakeRecord{
+		// 2024-01-02 08:00 in Tokyo
+		{Time: time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)},
+		{Time: time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC)},
+		{Time: time.Date(2024, 1, 4, 12, 0, 0, 0, time.UTC)},
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, tokyo)
+
+	days := dailyCounts(quakes, from, from, tokyo)
+	want := map[string]int{"2024-01-01": 0, "2024-01-02": 2, "2024-01-03": 0, "2024-01-04": 1}
+	if len(days) != len(want) {
+		t.Fatalf("Expected %d days, got %+v", len(want), days)
+	}
+	for _, day := range days {
+		if count, ok := want[day.day.Format(trendDateFormat)]; !ok || count != day.count {
+			t.Errorf("Expected %d earthquakes on %s, got %d", count, day.day.Format(trendDateFormat), day.count)
+		}
+	}
+}
+
+func TestDailyCountsDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// Clocks go forward on 2024-03-10, making it 23 hours long
+	from := time.Date(2024, 3, 9, 12, 0, 0, 0, newYork)
+	to := time.Date(2024, 3, 12, 12, 0, 0, 0, newYork)
+	days := dailyCounts(nil, from, to, newYork)
+	if len(days) != 4 {
+		t.Fatalf("Expected 4 days, got %d", len(days))
+	}
+	for _, day := range days {
+		if day.day.Hour() != 0 {
+			t.Errorf("Expected days to start at midnight, got %v", day.day)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	days := []dayCount{{count: 0}, {count: 1}, {count: 5}, {count: 10}}
+	if got := sparkline(days, 10); got != " .+@" {
+		t.Errorf("Expected %q, got %q", " .+@", got)
+	}
+}
+
+func TestPrintTrend(t *testing.T) {
+	quakes := []QuakeRecord{
+		{Time: time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{Time: time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)},
+		{Time: time.Date(2024, 1, 3, 1, 0, 0, 0, time.UTC)},
+	}
+	opts := options{inputPath: "feed.geojson", location: time.UTC}
+
+	var buf bytes.Buffer
+	printTrend(&buf, quakes, opts)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 || !strings.HasSuffix(lines[1], " 2") || !strings.HasSuffix(lines[2], " 0") || !strings.HasPrefix(lines[3], "2024-01-03 | #") {
+		t.Errorf("Expected a bar per day, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	opts.sparkline = true
+	printTrend(&buf, quakes, opts)
+	if !strings.Contains(buf.String(), "2024-01-01 to 2024-01-03 |@ +| peak 2 on 2024-01-01") {
+		t.Errorf("Expected a sparkline, got:\n%s", buf.String())
+	}
+}
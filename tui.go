@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// defaultTUIRefresh is how often -tui refetches the feed unless -watch gives
+// another interval.
+const defaultTUIRefresh = time.Minute
+
+// tuiSortKeys are the orders the s key cycles through; the empty key is the
+// order of the command line, feed order unless -sort or -top say otherwise.
+var tuiSortKeys = append([]string{""}, sortKeys...)
+
+// quakeBrowser is the -tui screen: a table of the matching earthquakes over
+// the details of the selected one, and a status line.
+type quakeBrowser struct {
+	app     *tview.Application
+	table   *tview.Table
+	details *tview.TextView
+	status  *tview.TextView
+
+	opts    options
+	sortKey string
+	refresh chan struct{}
+
+	// fetchedQuakes are the matches in the order of the command line;
+	// quakes are the rows of the table, in its current order
+	fetchedQuakes []QuakeRecord
+	quakes        []QuakeRecord
+	fetched       time.Time
+	err           error
+	// warning is the last warning logged during the current fetch
+	warning string
+}
+
+// newQuakeBrowser lays out the screen; the table is empty, and the status
+// says it is loading, until setQuakes.
+func newQuakeBrowser(opts options) *quakeBrowser {
+	b := &quakeBrowser{
+		app:     tview.NewApplication(),
+		table:   tview.NewTable(),
+		details: tview.NewTextView(),
+		status:  tview.NewTextView(),
+		opts:    opts,
+		sortKey: opts.sort,
+		refresh: make(chan struct{}, 1),
+	}
+
+	b.table.SetSelectable(true, false).SetFixed(1, 0)
+	b.table.SetBorder(true).SetTitle(" Earthquakes ")
+	b.table.SetSelectionChangedFunc(func(row, column int) { b.showDetails(row) })
+	b.details.SetScrollable(true).SetWrap(true)
+	b.details.SetBorder(true).SetTitle(" Details ")
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(b.table, 0, 3, true).
+		AddItem(b.details, 0, 2, false).
+		AddItem(b.status, 1, 0, false)
+	b.app.SetRoot(layout, true)
+	b.app.SetInputCapture(b.handleKey)
+	b.showStatus()
+
+	return b
+}
+
+// browseQuakes runs the terminal UI until the user quits or ctx is canceled,
+// refetching the feed every opts.watch, or defaultTUIRefresh.
+func browseQuakes(ctx context.Context, opts options) error {
+	// The details pane is plain text, with the map link always included
+	opts.colorize, opts.maps = false, true
+	interval := opts.watch
+	if interval == 0 {
+		interval = defaultTUIRefresh
+	}
+
+	b := newQuakeBrowser(opts)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// tview owns the terminal, so warnings go to the status line instead
+	// of stderr until the UI stops
+	previous, logOutput, logFlags := slog.Default(), log.Writer(), log.Flags()
+	slog.SetDefault(slog.New(statusHandler{b: b}))
+	defer func() {
+		slog.SetDefault(previous)
+		log.SetOutput(logOutput)
+		log.SetFlags(logFlags)
+	}()
+	go b.poll(ctx, interval)
+
+	return b.app.Run()
+}
+
+// statusHandler is the slog handler while the TUI runs: it shows warnings
+// and errors on the status line and drops the rest.
+type statusHandler struct {
+	b     *quakeBrowser
+	attrs []slog.Attr
+}
+
+func (h statusHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelWarn
+}
+
+func (h statusHandler) Handle(_ context.Context, r slog.Record) error {
+	text := statusLogText(r, h.attrs)
+	h.b.app.QueueUpdateDraw(func() {
+		h.b.warning = text
+		h.b.showStatus()
+	})
+	return nil
+}
+
+func (h statusHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return statusHandler{b: h.b, attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)}
+}
+
+func (h statusHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// statusLogText is a log record on one line: the message, then key=value
+// for each attribute.
+func statusLogText(r slog.Record, attrs []slog.Attr) string {
+	parts := []string{r.Message}
+	for _, a := range attrs {
+		parts = append(parts, a.String())
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		parts = append(parts, a.String())
+		return true
+	})
+	return strings.Join(parts, " ")
+}
+
+// poll fetches the earthquakes at start, every interval and whenever the r
+// key asks, handing them to the UI goroutine.
+func (b *quakeBrowser) poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Warnings from an earlier fetch no longer apply
+		b.app.QueueUpdate(func() { b.warning = "" })
+		earthquakeData, _, err := loadEarthquakeData(ctx, b.opts)
+		if ctx.Err() != nil {
+			b.app.Stop()
+			return
+		}
+		var quakes []QuakeRecord
+		if err == nil {
+			quakes = collectQuakes(earthquakeData, b.opts)
+			if b.opts.limit > 0 && len(quakes) > b.opts.limit {
+				quakes = quakes[:b.opts.limit]
+			}
+		}
+		b.app.QueueUpdateDraw(func() {
+			if err != nil {
+				// Keep showing the last good list
+				b.err = err
+				b.showStatus()
+				return
+			}
+			b.setQuakes(quakes, timeNow())
+		})
+
+		select {
+		case <-ctx.Done():
+			b.app.Stop()
+			return
+		case <-ticker.C:
+		case <-b.refresh:
+		}
+	}
+}
+
+// handleKey handles the keys the table does not: s cycles the sort order, r
+// refreshes, Tab moves between the table and the details, and q quits.
+func (b *quakeBrowser) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case event.Key() == tcell.KeyTab:
+		if b.table.HasFocus() {
+			b.app.SetFocus(b.details)
+		} else {
+			b.app.SetFocus(b.table)
+		}
+	case event.Rune() == 's':
+		b.cycleSort()
+	case event.Rune() == 'r':
+		select {
+		case b.refresh <- struct{}{}:
+		default:
+		}
+	case event.Rune() == 'q':
+		b.app.Stop()
+	default:
+		return event
+	}
+	return nil
+}
+
+// cycleSort moves on to the next order in tuiSortKeys, keeping the selected
+// earthquake selected.
+func (b *quakeBrowser) cycleSort() {
+	next := 0
+	for i, key := range tuiSortKeys {
+		if key == b.sortKey {
+			next = (i + 1) % len(tuiSortKeys)
+		}
+	}
+	b.sortKey = tuiSortKeys[next]
+	b.setQuakes(b.fetchedQuakes, b.fetched)
+}
+
+// setQuakes fills the table with quakes, given in the order of the command
+// line, in the current order, keeping the selection on the same event when it is still listed.
+func (b *quakeBrowser) setQuakes(quakes []QuakeRecord, fetched time.Time) {
+	selected := ""
+	if quake, ok := b.selectedQuake(); ok {
+		selected = quake.ID
+	}
+
+	b.fetchedQuakes, b.fetched, b.err = quakes, fetched, nil
+	quakes = append([]QuakeRecord(nil), quakes...)
+	sortQuakes(quakes, b.sortKey)
+	b.quakes = quakes
+
+	b.table.Clear()
+	for column, title := range []string{"Mag", "Place", "Time", "Depth"} {
+		b.table.SetCell(0, column, tview.NewTableCell(title).SetSelectable(false).SetTextColor(tcell.ColorYellow))
+	}
+	row := 1
+	for i, quake := range quakes {
+		magnitude := tview.NewTableCell("?").SetAlign(tview.AlignRight)
+		if !quake.unknownMag {
+			magnitude.SetText(strconv.FormatFloat(quake.Mag, 'f', 1, 64)).SetTextColor(tuiColor(quake.Mag))
+		}
+		depth := ""
+		if quake.hasDepth {
+			depth = formatLength(quake.Depth, 1, b.opts.units)
+		}
+		b.table.SetCell(i+1, 0, magnitude)
+		b.table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(quake.Place)).SetExpansion(1))
+		b.table.SetCell(i+1, 2, tview.NewTableCell(quake.Time.In(b.opts.location).Format(dateFormat)))
+		b.table.SetCell(i+1, 3, tview.NewTableCell(depth).SetAlign(tview.AlignRight))
+		if selected != "" && quake.ID == selected {
+			row = i + 1
+		}
+	}
+
+	b.table.Select(row, 0)
+	b.showStatus()
+}
+
+// selectedQuake returns the earthquake on the selected row, if any.
+func (b *quakeBrowser) selectedQuake() (QuakeRecord, bool) {
+	row, _ := b.table.GetSelection()
+	if row < 1 || row > len(b.quakes) {
+		return QuakeRecord{}, false
+	}
+	return b.quakes[row-1], true
+}
+
+// showDetails prints every property of the earthquake on row, including its
+// map link, as the text report does.
+func (b *quakeBrowser) showDetails(row int) {
+	b.details.Clear()
+	if row < 1 || row > len(b.quakes) {
+		return
+	}
+	var buf bytes.Buffer
+	printEarthquakeInfo(&buf, b.quakes[row-1], b.opts)
+	b.details.SetText(buf.String()).ScrollToBeginning()
+}
+
+// showStatus summarizes the list and the keys, or the last fetch error, with
+// any warning from the fetch. Until the first fetch it says so.
+func (b *quakeBrowser) showStatus() {
+	order, key := "feed order", b.sortKey
+	if key == "" {
+		key = b.opts.sort
+	}
+	if key != "" {
+		order = "sorted by " + key
+	}
+	warning := ""
+	if b.warning != "" {
+		warning = " | " + b.warning
+	}
+	text := fmt.Sprintf(" %s, %s | updated %s%s | s: sort  r: refresh  Tab: details  q: quit",
+		plural(len(b.quakes), "earthquake"), order, b.fetched.In(b.opts.location).Format("15:04:05"), warning)
+	switch {
+	case b.err != nil:
+		text = " Refresh failed: " + b.err.Error() + warning + " | r: retry  q: quit"
+	case b.fetched.IsZero():
+		text = " Loading earthquakes…" + warning + " | q: quit"
+	}
+	b.status.SetText(text)
+}
+
+// tuiSeverityColors is the table color for each severity.
+var tuiSeverityColors = [...]tcell.Color{
+	severityMinor:    tcell.ColorGreen,
+	severityModerate: tcell.ColorYellow,
+	severityStrong:   tcell.ColorOrange,
+	severityMajor:    tcell.ColorRed,
+}
+
+// tuiColor is the table color for a magnitude's severity.
+func tuiColor(mag float64) tcell.Color {
+	return tuiSeverityColors[magnitudeSeverity(mag)]
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestQuakeBrowser(t *testing.T) {
+	quakes := []QuakeRecord{
+		{ID: "a", Place: "Weak [offshore]", Mag: 2.1, Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "b", Place: "Strong", Mag: 7.2, Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "c", Place: "Unknown", unknownMag: true, Time: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	quakes[1].setCoordinates([]float64{142.1, 38.3, 10})
+
+	b := newQuakeBrowser(options{location: time.UTC, maps: true})
+	if got := b.status.GetText(true); !strings.Contains(got, "Loading earthquakes") || strings.Contains(got, "updated") {
+		t.Errorf("Expected a loading status before the first fetch, got %q", got)
+	}
+	b.setQuakes(quakes, time.Date(2024, 1, 3, 12, 30, 0, 0, time.UTC))
+
+	if rows := b.table.GetRowCount(); rows != 4 {
+		t.Fatalf("Expected a header and 3 rows, got %d", rows)
+	}
+	if got := b.table.GetCell(1, 1).Text; got != "Weak [offshore[]" {
+		t.Errorf("Expected the place escaped for tview, got %q", got)
+	}
+	cell := b.table.GetCell(2, 0)
+	if color, _, _ := cell.Style.Decompose(); cell.Text != "7.2" || color != tcell.ColorRed {
+		t.Errorf("Expected a red 7.2, got %q in %v", cell.Text, color)
+	}
+	if got := b.table.GetCell(3, 0).Text; got != "?" {
+		t.Errorf("Expected an unknown magnitude to show ?, got %q", got)
+	}
+	if got := b.status.GetText(true); !strings.Contains(got, "3 earthquakes, feed order | updated 12:30:00") {
+		t.Errorf("Unexpected status %q", got)
+	}
+
+	b.table.Select(2, 0)
+	details := b.details.GetText(true)
+	if !strings.Contains(details, "Epicenter = Strong\n") || !strings.Contains(details, "Map: ") {
+		t.Errorf("Expected the selected earthquake's details with a map link, got:\n%s", details)
+	}
+
+	// s sorts by magnitude, keeping the selected earthquake selected
+	b.handleKey(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone))
+	if b.sortKey != sortByMagnitude || b.quakes[0].ID != "b" || b.quakes[2].ID != "c" {
+		t.Errorf("Expected the strongest first, got %q: %+v", b.sortKey, b.quakes)
+	}
+	if quake, ok := b.selectedQuake(); !ok || quake.ID != "b" {
+		t.Errorf("Expected b to stay selected, got %+v", quake)
+	}
+	if got := b.status.GetText(true); !strings.Contains(got, "sorted by mag") {
+		t.Errorf("Expected the sort order in the status, got %q", got)
+	}
+
+	// Cycling through every key comes back to feed order
+	for range sortKeys {
+		b.handleKey(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone))
+	}
+	if b.sortKey != "" || b.quakes[0].ID != "a" {
+		t.Errorf("Expected feed order again, got %q: %+v", b.sortKey, b.quakes)
+	}
+
+	if event := b.handleKey(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)); event == nil {
+		t.Error("Expected navigation keys to be left to the table")
+	}
+	b.handleKey(tcell.NewEventKey(tcell.KeyRune, 'r', tcell.ModNone))
+	select {
+	case <-b.refresh:
+	default:
+		t.Error("Expected r to request a refresh")
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	b := newQuakeBrowser(options{location: time.UTC})
+	h := statusHandler{b: b}
+	if h.Enabled(context.Background(), slog.LevelInfo) || !h.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("Expected only warnings and errors to reach the status line")
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "Fetch failed, retrying", 0)
+	r.AddAttrs(slog.Int("attempt", 1))
+	got := statusLogText(r, []slog.Attr{slog.String("feed", "day")})
+	if got != "Fetch failed, retrying feed=day attempt=1" {
+		t.Errorf("Unexpected log text %q", got)
+	}
+
+	b.warning = got
+	b.setQuakes(nil, time.Date(2024, 1, 3, 12, 30, 0, 0, time.UTC))
+	if status := b.status.GetText(true); !strings.Contains(status, "updated 12:30:00 | Fetch failed, retrying feed=day attempt=1 |") {
+		t.Errorf("Expected the warning in the status, got %q", status)
+	}
+}
+
+func TestTUIColor(t *testing.T) {
+	tests := map[float64]tcell.Color{2.9: tcell.ColorGreen, 3: tcell.ColorYellow, 5.5: tcell.ColorOrange, 7: tcell.ColorRed}
+	for mag, expected := range tests {
+		if got := tuiColor(mag); got != expected {
+			t.Errorf("tuiColor(%v): expected %v, got %v", mag, expected, got)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// watchQuakes polls the feed every opts.watch and prints earthquakes whose
+// IDs were not seen in an earlier poll, until ctx is canceled. The first poll
+// prints the regular report; later polls print only what is new and alert on
+// it (-notify, -webhook, -slack). With -format jsonl every poll prints just a
+// line per new earthquake. With -metrics, each poll also updates the metrics
+// server. With -state, the seen IDs are loaded from and saved to that file,
+// so a new run only reports and alerts on earthquakes earlier runs have not;
+// only a first run, without a state file yet, keeps its first poll quiet.
+// Without -watch, it polls once, as from cron, and returns how many
+// earthquakes were new.
+func watchQuakes(ctx context.Context, w io.Writer, opts options) (int, error) {
+	seen := make(map[string]time.Time)
+	loaded := false
+	if opts.statePath != "" {
+		var err error
+		if seen, loaded, err = loadSeen(opts.statePath); err != nil {
+			return 0, fmt.Errorf("failed to load state: %w", err)
+		}
+	}
+	first := true
+
+	var metrics *quakeMetrics
+	if opts.metricsAddr != "" {
+		metrics = &quakeMetrics{}
+		server := serveMetrics(opts.metricsAddr, metrics)
+		defer server.Close()
+	}
+
+	var tick <-chan time.Time
+	if opts.watch > 0 {
+		ticker := time.NewTicker(opts.watch)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		earthquakeData, cached, err := loadEarthquakeData(ctx, opts)
+		switch {
+		case ctx.Err() != nil:
+			return 0, nil
+		case err != nil && opts.watch == 0:
+			return 0, err
+		case err != nil:
+			// Keep monitoring; the next poll may succeed
+			slog.Error("Failed to fetch earthquake data", "feed", opts.url, "err", err)
+			metrics.fetchFailed()
+		case cached && !first:
+			// Same data as the previous poll, nothing can be new
+			metrics.fetched()
+		default:
+			quakes := collectQuakes(earthquakeData, opts)
+			metrics.observe(quakes)
+			if opts.dbPath != "" {
+				if err := saveQuakes(opts.dbPath, quakes); err != nil {
+					slog.Error("Failed to save to database", "path", opts.dbPath, "err", err)
+				}
+			}
+			fresh := newQuakes(quakes, seen)
+			switch {
+			case opts.format == formatJSONL:
+				// One line per earthquake, without the report around them
+				if err := writeJSONL(w, fresh); err != nil {
+					return 0, fmt.Errorf("failed to write output: %w", err)
+				}
+			case first:
+				printQuakes(w, earthquakeData.Meta, fresh, len(fresh), opts)
+				printSummary(w, fresh, len(fresh), opts)
+			case len(fresh) > 0:
+				fmt.Fprintf(w, "%s at %s:\n", plural(len(fresh), "new earthquake"), time.Now().In(opts.location).Format(dateFormat))
+				fmt.Fprintln(w, "-------------------------------------------------------------------")
+				for _, quake := range fresh {
+					printEarthquakeInfo(w, quake, opts)
+				}
+			}
+			if !first || loaded {
+				alertQuakes(ctx, fresh, opts)
+			}
+			first = false
+
+			if opts.statePath != "" {
+				pruneSeen(seen, timeNow().Add(-feedWindow(opts.feed)))
+				if err := saveSeen(opts.statePath, seen); err != nil {
+					slog.Error("Failed to save state", "path", opts.statePath, "err", err)
+				}
+			}
+			if opts.watch == 0 {
+				return len(fresh), nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, nil
+		case <-tick:
+		}
+	}
+}
+
+// alertQuakes sends the notifications and deliveries the options ask for
+// about newly seen earthquakes.
+func alertQuakes(ctx context.Context, fresh []QuakeRecord, opts options) {
+	notifyQuakes(fresh, opts)
+	postWebhooks(ctx, fresh, opts)
+	postSlack(ctx, fresh, opts)
+}
+
+// newQuakes returns the earthquakes whose IDs are not in seen, and marks them
+// seen at their event time.
+func newQuakes(quakes []QuakeRecord, seen map[string]time.Time) []QuakeRecord {
+	var fresh []QuakeRecord
+	for _, quake := range quakes {
+		if _, ok := seen[quake.ID]; !ok {
+			seen[quake.ID] = quake.Time
+			fresh = append(fresh, quake)
+		}
+	}
+	return fresh
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewQuakes(t *testing.T) {
+	seen := make(map[string]time.Time)
+
+	first := newQuakes([]QuakeRecord{{ID: "a"}, {ID: "b"}}, seen)
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 new earthquakes on the first poll, got %d", len(first))
+	}
+
+	second := newQuakes([]QuakeRecord{{ID: "b"}, {ID: "c"}, {ID: "a"}}, seen)
+	if len(second) != 1 || second[0].ID != "c" {
+		t.Errorf("Expected only c to be new, got %+v", second)
+	}
+
+	if third := newQuakes([]QuakeRecord{{ID: "c"}}, seen); len(third) != 0 {
+		t.Errorf("Expected no new earthquakes, got %+v", third)
+	}
+}
+
+func TestWatchQuakesJSONL(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		features := `{"id": "a", "properties": {"mag": 4.1, "place": "First"}}`
+		if polls.Add(1) > 1 {
+			features += `, {"id": "b", "properties": {"mag": 5.2, "place": "Second"}}`
+		}
+		w.Write([]byte(`{"type": "FeatureCollection", "features": [` + features + `]}`))
+	}))
+	defer server.Close()
+
+	opts, err := parseFlags([]string{"-url", server.URL, "-watch", "5ms", "-format", "jsonl"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf syncBuffer
+	done := make(chan error)
+	go func() {
+		_, err := watchQuakes(ctx, &buf, opts)
+		done <- err
+	}()
+
+	for polls.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchQuakes() returned an error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"place":"First"`) || !strings.Contains(lines[1], `"place":"Second"`) {
+		t.Errorf("Expected one line per new earthquake, got:\n%s", buf.String())
+	}
+}
+
+func TestWatchQuakesAlertsAfterLoadingState(t *testing.T) {
+	// Store the original cache directory
+	originalCacheDir := cacheDir
+	defer func() { cacheDir = originalCacheDir }()
+	dir := t.TempDir()
+	cacheDir = func() (string, error) { return dir, nil }
+
+	// Events inside the feed window, so the state keeps them
+	origin := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"type": "FeatureCollection", "features": [
+			{"id": "a", "properties": {"mag": 4.1, "place": "Seen", "time": %d}},
+			{"id": "b", "properties": {"mag": 5.2, "place": "Missed", "time": %d}}
+		]}`, origin.UnixMilli(), origin.Add(time.Minute).UnixMilli())
+	}))
+	defer feed.Close()
+
+	var mu sync.Mutex
+	var delivered []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		delivered = append(delivered, payload.ID)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+	deliveries := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), delivered...)
+	}
+
+	// A cold start only records what is already in the feed
+	state := filepath.Join(t.TempDir(), "seen.json")
+	opts, err := parseFlags([]string{"-url", feed.URL, "-state", state, "-webhook", hook.URL})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	var buf bytes.Buffer
+	if count, err := watchQuakes(context.Background(), &buf, opts); err != nil || count != 2 {
+		t.Fatalf("Expected 2 new earthquakes on a cold start, got %d, %v", count, err)
+	}
+	if got := deliveries(); len(got) != 0 {
+		t.Errorf("Expected no alerts on a cold start, got %v", got)
+	}
+
+	// A watcher restarted while b came in alerts on it on its first poll
+	if err := saveSeen(state, map[string]time.Time{"a": origin}); err != nil {
+		t.Fatal(err)
+	}
+	opts, err = parseFlags([]string{"-url", feed.URL, "-state", state, "-webhook", hook.URL, "-watch", "1h"})
+	if err != nil {
+		t.Fatalf("parseFlags() returned an error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := watchQuakes(ctx, io.Discard, opts)
+		done <- err
+	}()
+	for deadline := time.Now().Add(5 * time.Second); len(deliveries()) == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watchQuakes() returned an error: %v", err)
+	}
+	if got := deliveries(); len(got) != 1 || got[0] != "b" {
+		t.Errorf("Expected the unseen earthquake to be delivered, got %v", got)
+	}
+
+	// So does a single cron run, which then finds nothing new
+	if err := saveSeen(state, map[string]time.Time{"a": origin}); err != nil {
+		t.Fatal(err)
+	}
+	opts.watch = 0
+	buf.Reset()
+	if count, err := watchQuakes(context.Background(), &buf, opts); err != nil || count != 1 {
+		t.Fatalf("Expected 1 new earthquake, got %d, %v", count, err)
+	}
+	if !strings.Contains(buf.String(), "Missed") || strings.Contains(buf.String(), "Seen") {
+		t.Errorf("Expected only the unseen earthquake in the report, got:\n%s", buf.String())
+	}
+	if got := deliveries(); len(got) != 2 || got[1] != "b" {
+		t.Errorf("Expected the cron run to deliver b, got %v", got)
+	}
+	if count, err := watchQuakes(context.Background(), io.Discard, opts); err != nil || count != 0 {
+		t.Errorf("Expected nothing new on the next run, got %d, %v", count, err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for a writer and a reader in different
+// goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout bounds each webhook delivery attempt.
+const webhookTimeout = 10 * time.Second
+
+// webhookPayload is the JSON body posted to -webhook for each new earthquake.
+// Mag and Depth are null when the feed gave none.
+type webhookPayload struct {
+	ID      string    `json:"id"`
+	Place   string    `json:"place"`
+	Mag     *float64  `json:"mag"`
+	MagType string    `json:"magType,omitempty"`
+	Depth   *float64  `json:"depth"`
+	Time    time.Time `json:"time"`
+	URL     string    `json:"url"`
+}
+
+// newWebhookPayload describes quake for -webhook.
+func newWebhookPayload(quake QuakeRecord) webhookPayload {
+	payload := webhookPayload{
+		ID:      quake.ID,
+		Place:   quake.Place,
+		MagType: quake.MagType,
+		Time:    quake.Time,
+		URL:     quake.URL,
+	}
+	if !quake.unknownMag {
+		mag := quake.Mag
+		payload.Mag = &mag
+	}
+	if quake.hasDepth {
+		depth := quake.Depth
+		payload.Depth = &depth
+	}
+	return payload
+}
+
+// postJSON POSTs payload as JSON to url, retrying once after retryBaseDelay
+// when the request fails or is not answered with a 2xx status.
+func postJSON(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := *httpClient
+	client.Timeout = webhookTimeout
+
+	for attempt := 0; ; attempt++ {
+		err = postOnce(ctx, &client, url, body)
+		if err == nil || attempt == 1 || ctx.Err() != nil {
+			return err
+		}
+		slog.Debug("Webhook delivery failed, retrying", "url", url, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryBaseDelay):
+		}
+	}
+}
+
+// postOnce makes a single POST attempt for postJSON.
+func postOnce(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postWebhooks delivers each earthquake to -webhook. Failed deliveries are
+// logged rather than stopping the watch.
+func postWebhooks(ctx context.Context, quakes []QuakeRecord, opts options) {
+	if opts.webhookURL == "" {
+		return
+	}
+
+	for _, quake := range quakes {
+		if err := postJSON(ctx, opts.webhookURL, newWebhookPayload(quake)); err != nil {
+			slog.Warn("Webhook delivery failed", "id", quake.ID, "err", err)
+		}
+	}
+}
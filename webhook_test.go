@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostWebhooks(t *testing.T) {
+	var payloads []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("Invalid JSON payload %q: %v", body, err)
+		}
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	quake := QuakeRecord{
+		ID:    "us1000abcd",
+		Place: "10 km S of Somewhere",
+		Mag:   6.1,
+		Time:  time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+		URL:   "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+	}
+	quake.setCoordinates([]float64{142.1, 38.3, 10})
+
+	postWebhooks(context.Background(), []QuakeRecord{quake, {ID: "b", unknownMag: true}}, options{webhookURL: server.URL})
+
+	if len(payloads) != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", len(payloads))
+	}
+	expected := map[string]any{
+		"id":    "us1000abcd",
+		"place": "10 km S of Somewhere",
+		"mag":   6.1,
+		"depth": 10.0,
+		"time":  "2024-01-02T12:00:00Z",
+		"url":   "https://earthquake.usgs.gov/earthquakes/eventpage/us1000abcd",
+	}
+	for key, value := range expected {
+		if payloads[0][key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, payloads[0][key])
+		}
+	}
+	if payloads[1]["mag"] != nil || payloads[1]["depth"] != nil {
+		t.Errorf("Expected null mag and depth for an unknown event, got %v", payloads[1])
+	}
+}
+
+func TestPostJSONRetriesOnce(t *testing.T) {
+	// Store the original retry delay
+	originalDelay := retryBaseDelay
+	defer func() { retryBaseDelay = originalDelay }()
+	retryBaseDelay = time.Millisecond
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	if err := postJSON(context.Background(), server.URL, map[string]string{}); err != nil {
+		t.Errorf("postJSON() expected the retry to succeed, got %v", err)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	calls = 0
+	if err := postJSON(context.Background(), failing.URL, map[string]string{}); err == nil {
+		t.Error("postJSON() expected an error, got nil")
+	}
+	if calls != 2 {
+		t.Errorf("Expected exactly one retry, got %d attempts", calls)
+	}
+}
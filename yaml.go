@@ -0,0 +1,23 @@
+package main
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeYAML prints the earthquakes as a YAML list. The keys mirror the JSON
+// output field for field.
+func writeYAML(w io.Writer, quakes []QuakeRecord) error {
+	if quakes == nil {
+		// Encode an empty result as [] rather than null
+		quakes = []QuakeRecord{}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(quakes); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteYAML(t *testing.T) {
+	felt := 3
+	quakes := []QuakeRecord{
+		{ID: "us1", Place: "Location 1", Mag: 6.1, MagType: "mww", Time: time.UnixMilli(1633455600000).UTC(), Felt: &felt},
+		{ID: "us2", Place: "Location 2", Mag: 4.5, Alert: "green"},
+	}
+	quakes[0].setCoordinates([]float64{142.1, 38.3, 10})
+
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, quakes); err != nil {
+		t.Fatalf("writeYAML() returned an error: %v", err)
+	}
+
+	var fromYAML []map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("writeYAML() produced invalid YAML: %v", err)
+	}
+
+	// The YAML keys must match the JSON ones
+	var jsonBuf bytes.Buffer
+	if err := writeJSON(&jsonBuf, quakes); err != nil {
+		t.Fatalf("writeJSON() returned an error: %v", err)
+	}
+	var fromJSON []map[string]interface{}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("writeJSON() produced invalid JSON: %v", err)
+	}
+
+	if len(fromYAML) != len(fromJSON) {
+		t.Fatalf("Expected %d YAML entries, got %d", len(fromJSON), len(fromYAML))
+	}
+	for i := range fromJSON {
+		if got, expected := keys(fromYAML[i]), keys(fromJSON[i]); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Entry %d: expected keys %v, got %v", i, expected, got)
+		}
+	}
+	if fromYAML[0]["magType"] != "mww" || fromYAML[0]["place"] != "Location 1" {
+		t.Errorf("Unexpected first entry: %v", fromYAML[0])
+	}
+}
+
+func TestWriteYAMLUnknownMagnitude(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, []QuakeRecord{{Place: "Unknown", unknownMag: true}}); err != nil {
+		t.Fatalf("writeYAML() returned an error: %v", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeYAML() produced invalid YAML: %v", err)
+	}
+	if mag, ok := decoded[0]["mag"]; !ok || mag != nil {
+		t.Errorf("Expected a null magnitude, got %v in:\n%s", mag, buf.String())
+	}
+}
+
+func TestWriteYAMLMissingCoordinates(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, []QuakeRecord{{Place: "Nowhere"}}); err != nil {
+		t.Fatalf("writeYAML() returned an error: %v", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeYAML() produced invalid YAML: %v", err)
+	}
+	for _, key := range []string{"longitude", "latitude", "depth"} {
+		if value, ok := decoded[0][key]; !ok || value != nil {
+			t.Errorf("Expected a null %s, got %v in:\n%s", key, value, buf.String())
+		}
+	}
+}
+
+func TestWriteYAMLEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, nil); err != nil {
+		t.Fatalf("writeYAML() returned an error: %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("Expected an empty list, got %q", buf.String())
+	}
+}
+
+// keys returns the set of keys in m.
+func keys(m map[string]interface{}) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for k := range m {
+		set[k] = true
+	}
+	return set
+}